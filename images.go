@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+	"strings"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	appbsky "github.com/bluesky-social/indigo/api/bsky"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	xrpc "github.com/bluesky-social/indigo/xrpc"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	maxImages         = 4         // app.bsky.embed.images allows at most 4 images
+	maxImageBytes     = 1_000_000 // PDS blob size limit for images
+	maxImageDimension = 2000      // longest side we downscale to
+)
+
+type imageInput struct {
+	Path string `json:"path"`
+	Data string `json:"data"`
+	Alt  string `json:"alt"`
+}
+
+// getImageInputs reads the "images" argument of a tool request.
+func getImageInputs(request mcp.CallToolRequest) ([]imageInput, error) {
+	raw, ok := request.GetArguments()["images"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var inputs []imageInput
+	if err := json.Unmarshal(b, &inputs); err != nil {
+		return nil, fmt.Errorf("images must be an array of objects: %w", err)
+	}
+	if len(inputs) > maxImages {
+		return nil, fmt.Errorf("at most %d images can be attached, got %d", maxImages, len(inputs))
+	}
+	for i, in := range inputs {
+		if strings.TrimSpace(in.Alt) == "" {
+			return nil, fmt.Errorf("image %d is missing alt text", i+1)
+		}
+		if (in.Path == "") == (in.Data == "") {
+			return nil, fmt.Errorf("image %d must have exactly one of path or data", i+1)
+		}
+	}
+	return inputs, nil
+}
+
+// makeImagesEmbed uploads each image and builds an app.bsky.embed.images embed.
+func makeImagesEmbed(ctx context.Context, c *xrpc.Client, inputs []imageInput, downscale bool) (*appbsky.EmbedImages, error) {
+	embed := &appbsky.EmbedImages{}
+	for i, in := range inputs {
+		data, err := loadImageData(in)
+		if err != nil {
+			return nil, fmt.Errorf("image %d: %w", i+1, err)
+		}
+		data, mimeType, aspect, err := prepareImage(data, downscale)
+		if err != nil {
+			return nil, fmt.Errorf("image %d: %w", i+1, err)
+		}
+		blob, err := uploadBlob(ctx, c, data, mimeType)
+		if err != nil {
+			return nil, fmt.Errorf("image %d: %w", i+1, err)
+		}
+		embed.Images = append(embed.Images, &appbsky.EmbedImages_Image{
+			Alt:         in.Alt,
+			AspectRatio: aspect,
+			Image:       blob,
+		})
+	}
+	return embed, nil
+}
+
+func loadImageData(in imageInput) ([]byte, error) {
+	if in.Path != "" {
+		return os.ReadFile(in.Path)
+	}
+
+	// tolerate data URLs (data:image/png;base64,....)
+	data := in.Data
+	if strings.HasPrefix(data, "data:") {
+		if i := strings.Index(data, ","); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	b, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding base64 image data: %w", err)
+	}
+	return b, nil
+}
+
+// prepareImage detects the MIME type and dimensions of an image, and if it is
+// over the size limit and downscale is set, shrinks it until it fits.
+func prepareImage(data []byte, downscale bool) ([]byte, string, *appbsky.EmbedDefs_AspectRatio, error) {
+	mimeType := http.DetectContentType(data)
+	switch mimeType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+	default:
+		return nil, "", nil, fmt.Errorf("unsupported image type %s", mimeType)
+	}
+
+	if len(data) > maxImageBytes {
+		if !downscale || (mimeType != "image/jpeg" && mimeType != "image/png") {
+			return nil, "", nil, fmt.Errorf("image is %d bytes, exceeding the limit of %d bytes", len(data), maxImageBytes)
+		}
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("error decoding image: %w", err)
+		}
+		data, err = shrinkImage(img, mimeType)
+		if err != nil {
+			return nil, "", nil, err
+		}
+	}
+
+	width, height, err := imageDimensions(data, mimeType)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return data, mimeType, &appbsky.EmbedDefs_AspectRatio{Width: int64(width), Height: int64(height)}, nil
+}
+
+func imageDimensions(data []byte, mimeType string) (int, int, error) {
+	if mimeType == "image/webp" {
+		return webpDimensions(data)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, fmt.Errorf("error reading image dimensions: %w", err)
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// webpDimensions reads the canvas size out of a WebP header, since the
+// standard library has no WebP decoder.
+func webpDimensions(data []byte) (int, int, error) {
+	if len(data) < 30 {
+		return 0, 0, fmt.Errorf("webp image too short")
+	}
+	switch string(data[12:16]) {
+	case "VP8 ":
+		w := binary.LittleEndian.Uint16(data[26:28]) & 0x3fff
+		h := binary.LittleEndian.Uint16(data[28:30]) & 0x3fff
+		return int(w), int(h), nil
+	case "VP8L":
+		bits := binary.LittleEndian.Uint32(data[21:25])
+		return int(bits&0x3fff) + 1, int((bits>>14)&0x3fff) + 1, nil
+	case "VP8X":
+		w := uint32(data[24]) | uint32(data[25])<<8 | uint32(data[26])<<16
+		h := uint32(data[27]) | uint32(data[28])<<8 | uint32(data[29])<<16
+		return int(w) + 1, int(h) + 1, nil
+	}
+	return 0, 0, fmt.Errorf("unrecognized webp format")
+}
+
+// shrinkImage re-encodes img, scaling it down further each round, until it
+// fits under maxImageBytes.
+func shrinkImage(img image.Image, mimeType string) ([]byte, error) {
+	bounds := img.Bounds()
+	scale := 1.0
+	if longest := max(bounds.Dx(), bounds.Dy()); longest > maxImageDimension {
+		scale = float64(maxImageDimension) / float64(longest)
+	}
+
+	for range 8 {
+		w := max(int(float64(bounds.Dx())*scale), 1)
+		h := max(int(float64(bounds.Dy())*scale), 1)
+		scaled := resizeImage(img, w, h)
+
+		var buf bytes.Buffer
+		var err error
+		if mimeType == "image/png" {
+			err = png.Encode(&buf, scaled)
+		} else {
+			err = jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: 85})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error encoding image: %w", err)
+		}
+		if buf.Len() <= maxImageBytes {
+			return buf.Bytes(), nil
+		}
+		scale *= 0.75
+	}
+
+	return nil, fmt.Errorf("could not shrink image under %d bytes", maxImageBytes)
+}
+
+// resizeImage scales img to w x h by averaging the source pixels covered by
+// each destination pixel.
+func resizeImage(img image.Image, w, h int) *image.RGBA64 {
+	src := img.Bounds()
+	dst := image.NewRGBA64(image.Rect(0, 0, w, h))
+	for y := range h {
+		y0 := src.Min.Y + y*src.Dy()/h
+		y1 := max(src.Min.Y+(y+1)*src.Dy()/h, y0+1)
+		for x := range w {
+			x0 := src.Min.X + x*src.Dx()/w
+			x1 := max(src.Min.X+(x+1)*src.Dx()/w, x0+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					b += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
+
+// uploadBlob uploads data to the PDS with the given content type.
+func uploadBlob(ctx context.Context, c *xrpc.Client, data []byte, mimeType string) (*lexutil.LexBlob, error) {
+	// comatproto.RepoUploadBlob always sends a Content-Type of */*, so call the endpoint directly
+	var out comatproto.RepoUploadBlob_Output
+	if err := c.Do(ctx, xrpc.Procedure, mimeType, "com.atproto.repo.uploadBlob", nil, bytes.NewReader(data), &out); err != nil {
+		return nil, fmt.Errorf("error uploading blob: %w", err)
+	}
+	return out.Blob, nil
+}
@@ -0,0 +1,162 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	appbsky "github.com/bluesky-social/indigo/api/bsky"
+)
+
+// EmbedView is whichever member of an embed view union is set. The unions on
+// posts, quoted posts and record-with-media embeds share these members, though
+// not all of them have every one.
+type EmbedView struct {
+	Images          *appbsky.EmbedImages_View
+	Video           *appbsky.EmbedVideo_View
+	External        *appbsky.EmbedExternal_View
+	Record          *appbsky.EmbedRecord_View
+	RecordWithMedia *appbsky.EmbedRecordWithMedia_View
+}
+
+func EmbedViewFromPost(e *appbsky.FeedDefs_PostView_Embed) EmbedView {
+	if e == nil {
+		return EmbedView{}
+	}
+	return EmbedView{e.EmbedImages_View, e.EmbedVideo_View, e.EmbedExternal_View, e.EmbedRecord_View, e.EmbedRecordWithMedia_View}
+}
+
+func EmbedViewFromQuote(e *appbsky.EmbedRecord_ViewRecord_Embeds_Elem) EmbedView {
+	if e == nil {
+		return EmbedView{}
+	}
+	return EmbedView{e.EmbedImages_View, e.EmbedVideo_View, e.EmbedExternal_View, e.EmbedRecord_View, e.EmbedRecordWithMedia_View}
+}
+
+func EmbedViewFromMedia(m *appbsky.EmbedRecordWithMedia_View_Media) EmbedView {
+	if m == nil {
+		return EmbedView{}
+	}
+	return EmbedView{Images: m.EmbedImages_View, Video: m.EmbedVideo_View, External: m.EmbedExternal_View}
+}
+
+// Embed renders what's embedded in a post: images with their alt text, a
+// video, a link card, and/or a quoted record. Quoted posts have their own
+// embeds rendered beneath them, indented.
+func Embed(e EmbedView) string {
+	str := ""
+	if e.Images != nil {
+		str += images(e.Images)
+	}
+	if e.Video != nil {
+		str += video(e.Video)
+	}
+	if e.External != nil {
+		str += external(e.External)
+	}
+	if e.Record != nil {
+		str += embeddedRecord(e.Record)
+	}
+	if e.RecordWithMedia != nil {
+		str += Embed(EmbedViewFromMedia(e.RecordWithMedia.Media))
+		if e.RecordWithMedia.Record != nil {
+			str += embeddedRecord(e.RecordWithMedia.Record)
+		}
+	}
+	return str
+}
+
+func images(v *appbsky.EmbedImages_View) string {
+	str := fmt.Sprintf("Embedded images (%d):\n", len(v.Images))
+	for i, img := range v.Images {
+		if img == nil {
+			continue
+		}
+		alt := "no alt text"
+		if strings.TrimSpace(img.Alt) != "" {
+			alt = fmt.Sprintf("alt text %q", img.Alt)
+		}
+		str += fmt.Sprintf("- Image %d, %s (%s)\n", i+1, alt, img.Fullsize)
+	}
+	return str
+}
+
+func video(v *appbsky.EmbedVideo_View) string {
+	alt := "no alt text"
+	if a := strings.TrimSpace(StringOrEmpty(v.Alt)); a != "" {
+		alt = fmt.Sprintf("alt text %q", a)
+	}
+	return fmt.Sprintf("Embedded video, %s (%s)\n", alt, v.Playlist)
+}
+
+func external(v *appbsky.EmbedExternal_View) string {
+	if v.External == nil {
+		return ""
+	}
+	str := fmt.Sprintf("Link card: %q (%s)", v.External.Title, v.External.Uri)
+	if d := strings.TrimSpace(v.External.Description); d != "" {
+		str += " — " + d
+	}
+	return str + "\n"
+}
+
+func embeddedRecord(v *appbsky.EmbedRecord_View) string {
+	r := v.Record
+	if r == nil {
+		return ""
+	}
+	switch {
+	case r.EmbedRecord_ViewRecord != nil:
+		q := r.EmbedRecord_ViewRecord
+		text := "(unavailable)"
+		if q.Value != nil {
+			if fp, ok := q.Value.Val.(*appbsky.FeedPost); ok {
+				text = fp.Text
+			}
+		}
+		str := fmt.Sprintf("Quoted post by %s (URI %s): %s\n", ProfileBasic(q.Author), q.Uri, text)
+		nested := ""
+		for _, e := range q.Embeds {
+			nested += Embed(EmbedViewFromQuote(e))
+		}
+		return str + Indent(nested, "    ")
+	case r.EmbedRecord_ViewNotFound != nil:
+		return fmt.Sprintf("Quoted post not found, it may have been deleted (URI %s)\n", r.EmbedRecord_ViewNotFound.Uri)
+	case r.EmbedRecord_ViewBlocked != nil:
+		return fmt.Sprintf("Quoted post hidden due to a block (URI %s)\n", r.EmbedRecord_ViewBlocked.Uri)
+	case r.EmbedRecord_ViewDetached != nil:
+		return fmt.Sprintf("Quoted post removed by its author (URI %s)\n", r.EmbedRecord_ViewDetached.Uri)
+	case r.FeedDefs_GeneratorView != nil:
+		g := r.FeedDefs_GeneratorView
+		return fmt.Sprintf("Embedded feed %q by %s (URI %s)\n", g.DisplayName, ProfileView(g.Creator), g.Uri)
+	case r.GraphDefs_ListView != nil:
+		l := r.GraphDefs_ListView
+		return fmt.Sprintf("Embedded list %q by %s (URI %s)\n", l.Name, ProfileView(l.Creator), l.Uri)
+	case r.LabelerDefs_LabelerView != nil:
+		l := r.LabelerDefs_LabelerView
+		return fmt.Sprintf("Embedded labeler by %s (URI %s)\n", ProfileView(l.Creator), l.Uri)
+	case r.GraphDefs_StarterPackViewBasic != nil:
+		sp := r.GraphDefs_StarterPackViewBasic
+		return fmt.Sprintf("Embedded starter pack %q by %s (URI %s)\n", StarterPackName(sp), ProfileBasic(sp.Creator), sp.Uri)
+	}
+	return "Embedded record of an unknown type\n"
+}
+
+func StarterPackName(sp *appbsky.GraphDefs_StarterPackViewBasic) string {
+	if sp.Record != nil {
+		if rec, ok := sp.Record.Val.(*appbsky.GraphStarterpack); ok {
+			return rec.Name
+		}
+	}
+	return ""
+}
+
+// Indent prefixes every line of s with indent.
+func Indent(s, indent string) string {
+	lines := strings.SplitAfter(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = indent + line
+		}
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,79 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	appbsky "github.com/bluesky-social/indigo/api/bsky"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+// ListPurposes maps the purposes accepted by the list tools to their lexicon
+// values.
+var ListPurposes = map[string]string{
+	"curate":  "app.bsky.graph.defs#curatelist",
+	"modlist": "app.bsky.graph.defs#modlist",
+}
+
+// ListPurposeName is the reverse of ListPurposes, for rendering lists.
+func ListPurposeName(purpose *string) string {
+	for name, p := range ListPurposes {
+		if p == StringOrEmpty(purpose) {
+			return name
+		}
+	}
+	if purpose != nil && strings.HasSuffix(*purpose, "#referencelist") {
+		return "reference"
+	}
+	return StringOrEmpty(purpose)
+}
+
+func List(l *appbsky.GraphDefs_ListView) string {
+	if l == nil {
+		return "Unknown list"
+	}
+	str := fmt.Sprintf("%q (%s list by %s, %d members, URI %s)", l.Name, ListPurposeName(l.Purpose), ProfileView(l.Creator), CountOrZero(l.ListItemCount), l.Uri)
+	if d := strings.TrimSpace(StringOrEmpty(l.Description)); d != "" {
+		str += "\nDescription: " + d
+	}
+	return str
+}
+
+func MutedWordExpired(w *appbsky.ActorDefs_MutedWord) bool {
+	if w.ExpiresAt == nil {
+		return false
+	}
+	t, err := syntax.ParseDatetimeLenient(*w.ExpiresAt)
+	return err == nil && t.Time().Before(time.Now())
+}
+
+func MutedWord(w *appbsky.ActorDefs_MutedWord) string {
+	var targets []string
+	for _, t := range w.Targets {
+		if t == nil {
+			continue
+		}
+		switch *t {
+		case "content":
+			targets = append(targets, "post text")
+		case "tag":
+			targets = append(targets, "tags")
+		default:
+			targets = append(targets, *t)
+		}
+	}
+	who := "everyone"
+	if StringOrEmpty(w.ActorTarget) == "exclude-following" {
+		who = "everyone except people you follow"
+	}
+	expires := "never expires"
+	if w.ExpiresAt != nil {
+		expires = "expires " + *w.ExpiresAt
+		if MutedWordExpired(w) {
+			expires = "expired " + *w.ExpiresAt
+		}
+	}
+	return fmt.Sprintf("%q (in %s, from %s, %s, ID %s)", w.Value, strings.Join(targets, " and "), who, expires, StringOrEmpty(w.Id))
+}
@@ -0,0 +1,176 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	appbsky "github.com/bluesky-social/indigo/api/bsky"
+)
+
+// PostCache holds posts fetched while rendering a single response, keyed by
+// URI, so that a post referenced by several notifications is fetched once.
+type PostCache map[string]*appbsky.FeedDefs_PostView
+
+// Text returns the text of the post at uri, or a placeholder if it wasn't
+// fetched.
+func (pc PostCache) Text(uri string) string {
+	p, ok := pc[uri]
+	if !ok || p == nil || p.Record == nil {
+		return "(unavailable)"
+	}
+	if post, ok := p.Record.Val.(*appbsky.FeedPost); ok {
+		return post.Text
+	}
+	return "(not a post)"
+}
+
+// NotificationPostSubject returns the URI of the post whose text should be
+// shown alongside n, if any.
+func NotificationPostSubject(n *appbsky.NotificationListNotifications_Notification) string {
+	switch n.Reason {
+	case "like", "repost", "quote":
+		return notificationSubject(n)
+	case "like-via-repost", "repost-via-repost":
+		return recordSubject(n)
+	case "reply":
+		if post := NotificationPost(n); post != nil && post.Reply != nil && post.Reply.Parent != nil {
+			return post.Reply.Parent.Uri
+		}
+	}
+	return ""
+}
+
+// Notifications renders notifications in order, one per line, with the text
+// of their subjects taken from pc. Likes of the same post are grouped into a
+// single line at the position of the most recent one.
+func Notifications(pc PostCache, notifications []*appbsky.NotificationListNotifications_Notification) string {
+	likesBySubject := map[string][]*appbsky.NotificationListNotifications_Notification{}
+	for _, n := range notifications {
+		if n.Reason == "like" {
+			subj := notificationSubject(n)
+			likesBySubject[subj] = append(likesBySubject[subj], n)
+		}
+	}
+
+	str := ""
+	grouped := map[string]bool{}
+	for _, n := range notifications {
+		if n.Reason == "like" {
+			subj := notificationSubject(n)
+			if likes := likesBySubject[subj]; len(likes) > 1 {
+				if !grouped[subj] {
+					grouped[subj] = true
+					str += likeGroup(pc, subj, likes)
+				}
+				continue
+			}
+		}
+		str += Notification(pc, n)
+	}
+	return str
+}
+
+func likeGroup(pc PostCache, subj string, likes []*appbsky.NotificationListNotifications_Notification) string {
+	var names []string
+	for _, n := range likes {
+		names = append(names, notificationAuthor(n))
+	}
+	return fmt.Sprintf("%d people liked your post (URI %s): %s\nLiked by: %s\n", len(likes), subj, pc.Text(subj), strings.Join(names, "; "))
+}
+
+func Notification(pc PostCache, n *appbsky.NotificationListNotifications_Notification) string {
+	author := notificationAuthor(n)
+	subj := notificationSubject(n)
+	post := NotificationPost(n)
+
+	switch n.Reason {
+	case "like":
+		return fmt.Sprintf("%s liked your post (URI %s): %s\n", author, subj, pc.Text(subj))
+	case "like-via-repost":
+		return fmt.Sprintf("%s liked your repost (URI %s) of a post (URI %s): %s\n", author, subj, recordSubject(n), pc.Text(recordSubject(n)))
+	case "repost":
+		return fmt.Sprintf("%s reposted your post (URI %s): %s\n", author, subj, pc.Text(subj))
+	case "repost-via-repost":
+		return fmt.Sprintf("%s reposted your repost (URI %s) of a post (URI %s): %s\n", author, subj, recordSubject(n), pc.Text(recordSubject(n)))
+	case "follow":
+		return fmt.Sprintf("%s followed you\n", author)
+	case "mention":
+		if post != nil {
+			return fmt.Sprintf("%s mentioned you (URI %s): %s\n", author, n.Uri, post.Text)
+		}
+	case "reply":
+		if post != nil && post.Reply != nil && post.Reply.Parent != nil {
+			parent := post.Reply.Parent.Uri
+			return fmt.Sprintf("%s replied to your post (URI %s, contents %s) with (URI %s): %s\n", author, parent, pc.Text(parent), n.Uri, post.Text)
+		}
+	case "quote":
+		if post != nil {
+			return fmt.Sprintf("%s quoted your post (URI %s, contents %s) with (URI %s): %s\n", author, subj, pc.Text(subj), n.Uri, post.Text)
+		}
+	case "starterpack-joined":
+		return fmt.Sprintf("%s joined Bluesky using your starter pack (URI %s)\n", author, subj)
+	case "verified":
+		return fmt.Sprintf("%s verified your account\n", author)
+	case "unverified":
+		return fmt.Sprintf("%s removed their verification of your account\n", author)
+	case "subscribed-post":
+		if post != nil {
+			return fmt.Sprintf("%s, whose posts you're subscribed to, posted (URI %s): %s\n", author, n.Uri, post.Text)
+		}
+	}
+
+	str := fmt.Sprintf("%s sent a %q notification (URI %s", author, n.Reason, n.Uri)
+	if subj != "" {
+		str += fmt.Sprintf(", subject %s", subj)
+	}
+	str += ")"
+	if post != nil {
+		str += ": " + post.Text
+	}
+	return str + "\n"
+}
+
+// NotificationPost returns the post that caused n, for reasons like replies
+// and mentions, or nil if n wasn't caused by a post.
+func NotificationPost(n *appbsky.NotificationListNotifications_Notification) *appbsky.FeedPost {
+	if n.Record == nil {
+		return nil
+	}
+	post, _ := n.Record.Val.(*appbsky.FeedPost)
+	return post
+}
+
+func notificationAuthor(n *appbsky.NotificationListNotifications_Notification) string {
+	if n.Author == nil {
+		return "Unknown user"
+	}
+	return Actor(n.Author.DisplayName, n.Author.Handle, n.Author.Did)
+}
+
+// notificationSubject returns the URI of what the notification is about, e.g.
+// the liked post or the repost that was liked.
+func notificationSubject(n *appbsky.NotificationListNotifications_Notification) string {
+	if n.ReasonSubject != nil {
+		return *n.ReasonSubject
+	}
+	return recordSubject(n)
+}
+
+// recordSubject returns the subject of a like or repost record, which for
+// via-repost notifications is the original post rather than the repost.
+func recordSubject(n *appbsky.NotificationListNotifications_Notification) string {
+	if n.Record == nil {
+		return ""
+	}
+	switch rec := n.Record.Val.(type) {
+	case *appbsky.FeedLike:
+		if rec.Subject != nil {
+			return rec.Subject.Uri
+		}
+	case *appbsky.FeedRepost:
+		if rec.Subject != nil {
+			return rec.Subject.Uri
+		}
+	}
+	return ""
+}
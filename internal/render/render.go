@@ -0,0 +1,204 @@
+// Package render renders API responses as text for the model to read.
+//
+// The API leaves out most optional fields when they're empty (display names,
+// counts, cursors, even whole views for deleted or blocked content), so
+// nothing here may assume a pointer is set.
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	appbsky "github.com/bluesky-social/indigo/api/bsky"
+)
+
+func StringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func CursorOrEmpty(cursor *string) string {
+	return StringOrEmpty(cursor)
+}
+
+func CountOrZero(n *int64) int64 {
+	if n == nil {
+		return 0
+	}
+	return *n
+}
+
+// Commit renders the commit a write was made in. Deleting a record that's
+// already gone makes no commit, so there may not be one.
+func Commit(commit *comatproto.RepoDefs_CommitMeta) string {
+	if commit == nil {
+		return ""
+	}
+	return fmt.Sprintf(" Commit CID: %s", commit.Cid)
+}
+
+// Actor renders an actor as their display name (or handle, if they don't
+// have one), handle, and DID.
+func Actor(displayName *string, handle, did string) string {
+	name := handle
+	if displayName != nil && strings.TrimSpace(*displayName) != "" {
+		name = *displayName
+	}
+	return fmt.Sprintf("%s (@%s, DID %s)", name, handle, did)
+}
+
+func ProfileBasic(a *appbsky.ActorDefs_ProfileViewBasic) string {
+	if a == nil {
+		return "Unknown user"
+	}
+	return Actor(a.DisplayName, a.Handle, a.Did)
+}
+
+// ProfileView renders an actor along with their bio, as in lists of
+// followers.
+func ProfileView(a *appbsky.ActorDefs_ProfileView) string {
+	if a == nil {
+		return "Unknown user"
+	}
+	str := Actor(a.DisplayName, a.Handle, a.Did)
+	if bio := strings.TrimSpace(StringOrEmpty(a.Description)); bio != "" {
+		str += " — " + bio
+	}
+	return str
+}
+
+// Profile renders a full profile. pronouns are any pronoun labels found for
+// the actor.
+func Profile(profile *appbsky.ActorDefs_ProfileViewDetailed, pronouns []string) string {
+	if profile == nil {
+		return "Profile not found\n"
+	}
+
+	verified := "No"
+	if v := profile.Verification; v != nil && (v.TrustedVerifierStatus == "valid" || v.VerifiedStatus == "valid") {
+		verified = "Yes"
+	}
+
+	str := fmt.Sprintf("Profile of %s:\n", Actor(profile.DisplayName, profile.Handle, profile.Did))
+	str += fmt.Sprintf("Handle: %s\n", profile.Handle)
+	str += fmt.Sprintf("Verified: %s\n", verified)
+	str += fmt.Sprintf("Bio: %s\n", StringOrEmpty(profile.Description))
+	str += fmt.Sprintf("Followers: %d\n", CountOrZero(profile.FollowersCount))
+	str += fmt.Sprintf("Following: %d\n", CountOrZero(profile.FollowsCount))
+	str += fmt.Sprintf("Posts: %d\n", CountOrZero(profile.PostsCount))
+	for _, p := range pronouns {
+		str += fmt.Sprintf("Pronouns: %s\n", p)
+	}
+	return str
+}
+
+func FeedGenerator(feedGen *appbsky.FeedGetFeedGenerator_Output) string {
+	if feedGen == nil || feedGen.View == nil {
+		return "Unknown feed\n"
+	}
+	isOnline := "Currently online"
+	if !feedGen.IsOnline {
+		isOnline = "Currently offline"
+	}
+	v := feedGen.View
+	return fmt.Sprintf("%s URI: %s, (%s, %d likes) — %s\n", v.DisplayName, v.Uri, isOnline, CountOrZero(v.LikeCount), StringOrEmpty(v.Description))
+}
+
+func Posts(posts []*appbsky.FeedDefs_FeedViewPost) string {
+	str := ""
+	for _, post := range posts {
+		if post == nil || post.Post == nil {
+			continue
+		}
+		p := post.Post
+		switch {
+		case post.Reason != nil && post.Reason.FeedDefs_ReasonPin != nil:
+			str += fmt.Sprintf("Pinned post by %s", ProfileBasic(p.Author))
+		case post.Reason != nil && post.Reason.FeedDefs_ReasonRepost != nil:
+			str += fmt.Sprintf("%s reposted a post by %s",
+				ProfileBasic(post.Reason.FeedDefs_ReasonRepost.By),
+				ProfileBasic(p.Author))
+		default:
+			str += fmt.Sprintf("Post by %s", ProfileBasic(p.Author))
+		}
+		str += PostBody(p)
+	}
+	return str
+}
+
+func PostViews(postViews *[]*appbsky.FeedDefs_PostView) string {
+	if postViews == nil {
+		return ""
+	}
+	str := ""
+	for _, p := range *postViews {
+		str += PostView(p)
+	}
+	return str
+}
+
+func PostView(p *appbsky.FeedDefs_PostView) string {
+	if p == nil {
+		return ""
+	}
+	return fmt.Sprintf("Post by %s", ProfileBasic(p.Author)) + PostBody(p)
+}
+
+// PostBody renders everything about a post after who posted it: counts, URI,
+// time, text, facets and embeds.
+func PostBody(p *appbsky.FeedDefs_PostView) string {
+	var fp *appbsky.FeedPost
+	if p.Record != nil {
+		fp, _ = p.Record.Val.(*appbsky.FeedPost)
+	}
+	createdAt := p.IndexedAt
+	if fp != nil {
+		createdAt = fp.CreatedAt
+	}
+
+	str := fmt.Sprintf(" with %d likes, %d quotes, %d replies, a URI of %s, and a posting time of %s:\n",
+		CountOrZero(p.LikeCount),
+		CountOrZero(p.QuoteCount),
+		CountOrZero(p.ReplyCount),
+		p.Uri,
+		createdAt)
+	if fp == nil {
+		return str + "Text: (unavailable)\n"
+	}
+	str += fmt.Sprintf("Text: %s\n", fp.Text)
+	if facets := facetList(fp); len(facets) > 0 {
+		str += "Facets:\n"
+		for _, facet := range facets {
+			str += fmt.Sprintf("- %s\n", facet)
+		}
+	}
+	str += Embed(EmbedViewFromPost(p.Embed))
+	return str
+}
+
+func facetList(post *appbsky.FeedPost) []string {
+	var facets []string
+	for _, facet := range post.Facets {
+		if facet == nil || facet.Index == nil {
+			continue
+		}
+		for _, feature := range facet.Features {
+			if feature == nil {
+				continue
+			}
+			if feature.RichtextFacet_Link != nil {
+				facets = append(facets, fmt.Sprintf("Link from byte %d to byte %d: %s", facet.Index.ByteStart, facet.Index.ByteEnd, feature.RichtextFacet_Link.Uri))
+			}
+			if feature.RichtextFacet_Mention != nil {
+				facets = append(facets, fmt.Sprintf("Mention from byte %d to byte %d: %s", facet.Index.ByteStart, facet.Index.ByteEnd, feature.RichtextFacet_Mention.Did))
+			}
+			if feature.RichtextFacet_Tag != nil {
+				facets = append(facets, fmt.Sprintf("Tag from byte %d to byte %d: %s", facet.Index.ByteStart, facet.Index.ByteEnd, feature.RichtextFacet_Tag.Tag))
+			}
+		}
+	}
+	return facets
+}
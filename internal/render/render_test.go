@@ -0,0 +1,145 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	appbsky "github.com/bluesky-social/indigo/api/bsky"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+)
+
+// The API leaves out optional fields when they're empty, so these feed the
+// renderers views with as little set as the lexicons allow.
+
+func sparsePost(uri string) *appbsky.FeedDefs_PostView {
+	return &appbsky.FeedDefs_PostView{
+		Uri:       uri,
+		Author:    &appbsky.ActorDefs_ProfileViewBasic{Did: "did:plc:alice", Handle: "alice.test"},
+		IndexedAt: "2025-01-01T00:00:00Z",
+		Record:    &lexutil.LexiconTypeDecoder{Val: &appbsky.FeedPost{Text: "hello"}},
+	}
+}
+
+// sparseEmbeds are embeds whose optional members are all left out.
+var sparseEmbeds = []*appbsky.FeedDefs_PostView_Embed{
+	{EmbedImages_View: &appbsky.EmbedImages_View{Images: []*appbsky.EmbedImages_ViewImage{nil}}},
+	{EmbedVideo_View: &appbsky.EmbedVideo_View{}},
+	{EmbedExternal_View: &appbsky.EmbedExternal_View{}},
+	{EmbedRecord_View: &appbsky.EmbedRecord_View{}},
+	{EmbedRecord_View: &appbsky.EmbedRecord_View{Record: &appbsky.EmbedRecord_View_Record{}}},
+	{EmbedRecord_View: &appbsky.EmbedRecord_View{Record: &appbsky.EmbedRecord_View_Record{
+		EmbedRecord_ViewRecord: &appbsky.EmbedRecord_ViewRecord{Uri: "at://did:plc:bob/app.bsky.feed.post/1", Embeds: []*appbsky.EmbedRecord_ViewRecord_Embeds_Elem{nil}},
+	}}},
+	{EmbedRecord_View: &appbsky.EmbedRecord_View{Record: &appbsky.EmbedRecord_View_Record{
+		FeedDefs_GeneratorView: &appbsky.FeedDefs_GeneratorView{Uri: "at://did:plc:bob/app.bsky.feed.generator/1"},
+	}}},
+	{EmbedRecord_View: &appbsky.EmbedRecord_View{Record: &appbsky.EmbedRecord_View_Record{
+		GraphDefs_StarterPackViewBasic: &appbsky.GraphDefs_StarterPackViewBasic{Uri: "at://did:plc:bob/app.bsky.graph.starterpack/1"},
+	}}},
+	{EmbedRecordWithMedia_View: &appbsky.EmbedRecordWithMedia_View{}},
+	{EmbedRecordWithMedia_View: &appbsky.EmbedRecordWithMedia_View{Media: &appbsky.EmbedRecordWithMedia_View_Media{}}},
+}
+
+func TestPostsSparse(t *testing.T) {
+	noAuthor := sparsePost("at://did:plc:alice/app.bsky.feed.post/2")
+	noAuthor.Author = nil
+	noRecord := sparsePost("at://did:plc:alice/app.bsky.feed.post/3")
+	noRecord.Record = nil
+
+	posts := []*appbsky.FeedDefs_FeedViewPost{
+		nil,
+		{},
+		{Post: sparsePost("at://did:plc:alice/app.bsky.feed.post/1")},
+		{Post: noAuthor, Reason: &appbsky.FeedDefs_FeedViewPost_Reason{}},
+		{Post: noRecord, Reason: &appbsky.FeedDefs_FeedViewPost_Reason{FeedDefs_ReasonRepost: &appbsky.FeedDefs_ReasonRepost{}}},
+		{Post: sparsePost("at://did:plc:alice/app.bsky.feed.post/4"), Reason: &appbsky.FeedDefs_FeedViewPost_Reason{FeedDefs_ReasonPin: &appbsky.FeedDefs_ReasonPin{}}},
+	}
+
+	got := Posts(posts)
+	for _, want := range []string{
+		"Post by alice.test (@alice.test, DID did:plc:alice) with 0 likes, 0 quotes, 0 replies",
+		"Post by Unknown user with",
+		"Unknown user reposted a post by alice.test",
+		"Text: (unavailable)\n",
+		"Pinned post by alice.test",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Posts() = %q, want it to contain %q", got, want)
+		}
+	}
+	if n := strings.Count(got, "a URI of "); n != 4 {
+		t.Errorf("Posts() rendered %d posts, want 4", n)
+	}
+}
+
+func TestPostBodySparse(t *testing.T) {
+	p := sparsePost("at://did:plc:alice/app.bsky.feed.post/1")
+	p.Record = nil
+	if got, want := PostBody(p), "a posting time of 2025-01-01T00:00:00Z:\nText: (unavailable)\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("PostBody() with no record = %q, want it to end with %q", got, want)
+	}
+
+	p.Record = &lexutil.LexiconTypeDecoder{Val: &appbsky.FeedPost{
+		Text:   "hello",
+		Facets: []*appbsky.RichtextFacet{nil, {}, {Index: &appbsky.RichtextFacet_ByteSlice{}, Features: []*appbsky.RichtextFacet_Features_Elem{nil}}},
+	}}
+	for _, embed := range sparseEmbeds {
+		p.Embed = embed
+		got := PostBody(p)
+		if !strings.Contains(got, "Text: hello\n") || strings.Contains(got, "Facets:") {
+			t.Errorf("PostBody() with embed %+v = %q", embed, got)
+		}
+	}
+}
+
+func TestProfileSparse(t *testing.T) {
+	if got, want := Profile(nil, nil), "Profile not found\n"; got != want {
+		t.Errorf("Profile(nil) = %q, want %q", got, want)
+	}
+
+	empty := ""
+	got := Profile(&appbsky.ActorDefs_ProfileViewDetailed{Did: "did:plc:alice", Handle: "alice.test", DisplayName: &empty}, nil)
+	want := "Profile of alice.test (@alice.test, DID did:plc:alice):\n" +
+		"Handle: alice.test\n" +
+		"Verified: No\n" +
+		"Bio: \n" +
+		"Followers: 0\n" +
+		"Following: 0\n" +
+		"Posts: 0\n"
+	if got != want {
+		t.Errorf("Profile() = %q, want %q", got, want)
+	}
+}
+
+func TestNotificationSparse(t *testing.T) {
+	tests := []struct {
+		reason string
+		want   string
+	}{
+		{"like", "Unknown user liked your post (URI ): (unavailable)\n"},
+		{"follow", "Unknown user followed you\n"},
+		{"mention", "Unknown user sent a \"mention\" notification (URI at://did:plc:bob/app.bsky.feed.post/1)\n"},
+		{"reply", "Unknown user sent a \"reply\" notification (URI at://did:plc:bob/app.bsky.feed.post/1)\n"},
+		{"quote", "Unknown user sent a \"quote\" notification (URI at://did:plc:bob/app.bsky.feed.post/1)\n"},
+		{"something-new", "Unknown user sent a \"something-new\" notification (URI at://did:plc:bob/app.bsky.feed.post/1)\n"},
+	}
+
+	for _, tt := range tests {
+		n := &appbsky.NotificationListNotifications_Notification{Uri: "at://did:plc:bob/app.bsky.feed.post/1", Reason: tt.reason}
+		if got := Notification(PostCache{}, n); got != tt.want {
+			t.Errorf("Notification(%q) = %q, want %q", tt.reason, got, tt.want)
+		}
+	}
+
+	// a reply whose record doesn't say what it replies to
+	n := &appbsky.NotificationListNotifications_Notification{
+		Uri:    "at://did:plc:bob/app.bsky.feed.post/1",
+		Reason: "reply",
+		Author: &appbsky.ActorDefs_ProfileView{Did: "did:plc:bob", Handle: "bob.test"},
+		Record: &lexutil.LexiconTypeDecoder{Val: &appbsky.FeedPost{Text: "hi", Reply: &appbsky.FeedPost_ReplyRef{}}},
+	}
+	want := "bob.test (@bob.test, DID did:plc:bob) sent a \"reply\" notification (URI at://did:plc:bob/app.bsky.feed.post/1): hi\n"
+	if got := Notification(PostCache{}, n); got != want {
+		t.Errorf("Notification() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package render
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	appbsky "github.com/bluesky-social/indigo/api/bsky"
+)
+
+// ThreadNode is whichever member of a thread union is set. The parent, reply
+// and top-level unions all have the same three members.
+type ThreadNode struct {
+	Post     *appbsky.FeedDefs_ThreadViewPost
+	NotFound *appbsky.FeedDefs_NotFoundPost
+	Blocked  *appbsky.FeedDefs_BlockedPost
+}
+
+func ThreadNodeFromThread(t *appbsky.FeedGetPostThread_Output_Thread) ThreadNode {
+	return ThreadNode{t.FeedDefs_ThreadViewPost, t.FeedDefs_NotFoundPost, t.FeedDefs_BlockedPost}
+}
+
+func ThreadNodeFromParent(p *appbsky.FeedDefs_ThreadViewPost_Parent) ThreadNode {
+	return ThreadNode{p.FeedDefs_ThreadViewPost, p.FeedDefs_NotFoundPost, p.FeedDefs_BlockedPost}
+}
+
+func ThreadNodeFromReply(r *appbsky.FeedDefs_ThreadViewPost_Replies_Elem) ThreadNode {
+	return ThreadNode{r.FeedDefs_ThreadViewPost, r.FeedDefs_NotFoundPost, r.FeedDefs_BlockedPost}
+}
+
+// Thread renders a thread: the chain of parents from the root down, then the
+// requested post, then its replies indented by depth. Posts by viewerDid are
+// marked as the logged in user's.
+func Thread(thread *appbsky.FeedGetPostThread_Output_Thread, viewerDid string) string {
+	anchor := ThreadNodeFromThread(thread)
+	if anchor.Post == nil {
+		return threadItem(anchor, 0, viewerDid)
+	}
+
+	var parents []ThreadNode
+	for p := anchor.Post.Parent; p != nil; {
+		node := ThreadNodeFromParent(p)
+		parents = append(parents, node)
+		if node.Post == nil {
+			break
+		}
+		p = node.Post.Parent
+	}
+	slices.Reverse(parents)
+
+	str := ""
+	if len(parents) > 0 {
+		str += fmt.Sprintf("Parent posts (%d, starting from the root):\n", len(parents))
+		for _, parent := range parents {
+			str += threadItem(parent, 0, viewerDid)
+		}
+	}
+	str += "Requested post:\n"
+	str += threadItem(ThreadNode{Post: anchor.Post}, 0, viewerDid)
+	if len(anchor.Post.Replies) > 0 {
+		str += "Replies (nested replies are indented):\n"
+		str += threadReplies(anchor.Post.Replies, 0, viewerDid)
+	}
+	return str
+}
+
+func threadReplies(replies []*appbsky.FeedDefs_ThreadViewPost_Replies_Elem, depth int, viewerDid string) string {
+	str := ""
+	for _, reply := range replies {
+		node := ThreadNodeFromReply(reply)
+		str += threadItem(node, depth, viewerDid)
+		if node.Post != nil {
+			str += threadReplies(node.Post.Replies, depth+1, viewerDid)
+		}
+	}
+	return str
+}
+
+// threadItem renders a single post of a thread, without its parents or
+// replies, indented by depth.
+func threadItem(node ThreadNode, depth int, viewerDid string) string {
+	str := ""
+	switch {
+	case node.Post != nil && node.Post.Post != nil:
+		p := node.Post.Post
+		if p.Author != nil && p.Author.Did == viewerDid {
+			str += "(Your post) "
+		}
+		str += PostView(p)
+	case node.NotFound != nil:
+		str += fmt.Sprintf("Post not found, it may have been deleted (URI %s)\n", node.NotFound.Uri)
+	case node.Blocked != nil:
+		did := ""
+		if node.Blocked.Author != nil {
+			did = node.Blocked.Author.Did
+		}
+		str += fmt.Sprintf("Post hidden due to a block, by DID %s (URI %s)\n", did, node.Blocked.Uri)
+	default:
+		str += "Unknown thread item\n"
+	}
+
+	return Indent(str, strings.Repeat("    ", depth))
+}
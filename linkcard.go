@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	appbsky "github.com/bluesky-social/indigo/api/bsky"
+	xrpc "github.com/bluesky-social/indigo/xrpc"
+
+	"golang.org/x/net/html"
+)
+
+type linkMetadata struct {
+	Title       string
+	Description string
+	Image       string // absolute URL, empty if the page has none
+}
+
+// linkFetcher fetches what's needed to build a link card. It's swappable so
+// that it can be pointed at a local stand-in.
+type linkFetcher interface {
+	FetchMetadata(ctx context.Context, uri string) (*linkMetadata, error)
+	FetchImage(ctx context.Context, uri string) ([]byte, error)
+}
+
+type httpLinkFetcher struct {
+	client        *http.Client
+	maxPageBytes  int64
+	maxImageBytes int64
+}
+
+var defaultLinkFetcher linkFetcher = &httpLinkFetcher{
+	client: &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			// no proxy, since it would hide the real destination from the dialer
+			DialContext:         (&net.Dialer{Timeout: 10 * time.Second, Control: dialPublicOnly}).DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+	},
+	maxPageBytes:  2_000_000,
+	maxImageBytes: 10_000_000,
+}
+
+// cgnatPrefix is the shared address space carriers use, which isn't covered
+// by netip.Addr.IsPrivate.
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// dialPublicOnly refuses connections to loopback, private, link-local and
+// other non-public addresses, so that the model can't use link cards to reach
+// the local network. It runs after DNS resolution, for redirects too.
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || cgnatPrefix.Contains(ip) {
+		return fmt.Errorf("refusing to connect to non-public address %s", ip)
+	}
+	return nil
+}
+
+func (f *httpLinkFetcher) get(ctx context.Context, uri string, limit int64) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", *userAgent())
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("%s returned status %d", uri, resp.StatusCode)
+	}
+
+	// read one byte past the limit to tell if the body was too large
+	b, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(b)) > limit {
+		return nil, "", fmt.Errorf("%s is larger than %d bytes", uri, limit)
+	}
+	return b, resp.Request.URL.String(), nil
+}
+
+func (f *httpLinkFetcher) FetchMetadata(ctx context.Context, uri string) (*linkMetadata, error) {
+	b, final, err := f.get(ctx, uri, f.maxPageBytes)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := html.Parse(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing page: %w", err)
+	}
+
+	meta := parseLinkMetadata(doc)
+	if meta.Image != "" {
+		// images are often relative to the page, after redirects
+		base, err := url.Parse(final)
+		if err == nil {
+			if img, err := base.Parse(meta.Image); err == nil {
+				meta.Image = img.String()
+			}
+		}
+	}
+	return meta, nil
+}
+
+func (f *httpLinkFetcher) FetchImage(ctx context.Context, uri string) ([]byte, error) {
+	b, _, err := f.get(ctx, uri, f.maxImageBytes)
+	return b, err
+}
+
+// parseLinkMetadata extracts Open Graph metadata from a page, falling back to
+// Twitter cards and plain HTML for anything missing.
+func parseLinkMetadata(doc *html.Node) *linkMetadata {
+	props := map[string]string{}
+	var title string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "meta":
+				var key, content string
+				for _, a := range n.Attr {
+					switch strings.ToLower(a.Key) {
+					case "property", "name":
+						key = strings.ToLower(a.Val)
+					case "content":
+						content = strings.TrimSpace(a.Val)
+					}
+				}
+				if _, ok := props[key]; key != "" && !ok {
+					props[key] = content
+				}
+			case "title":
+				if title == "" && n.FirstChild != nil {
+					title = strings.TrimSpace(n.FirstChild.Data)
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+
+	first := func(keys ...string) string {
+		for _, k := range keys {
+			if v := props[k]; v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	meta := &linkMetadata{
+		Title:       first("og:title", "twitter:title"),
+		Description: first("og:description", "twitter:description", "description"),
+		Image:       first("og:image", "og:image:url", "twitter:image"),
+	}
+	if meta.Title == "" {
+		meta.Title = title
+	}
+	return meta
+}
+
+// makeExternalEmbed fetches the page at uri and builds an app.bsky.embed.external
+// card from its metadata. A thumbnail that can't be fetched or uploaded is
+// left out rather than failing the whole card.
+func makeExternalEmbed(ctx context.Context, c *xrpc.Client, fetcher linkFetcher, uri string) (*appbsky.EmbedExternal, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid link card url: %s", uri)
+	}
+
+	meta, err := fetcher.FetchMetadata(ctx, uri)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching link metadata: %w", err)
+	}
+
+	external := &appbsky.EmbedExternal_External{
+		Uri:         uri,
+		Title:       meta.Title,
+		Description: meta.Description,
+	}
+	if meta.Image != "" {
+		data, err := fetcher.FetchImage(ctx, meta.Image)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error fetching link card thumbnail:", err)
+		} else if data, mimeType, _, err := prepareImage(data, true); err != nil {
+			fmt.Fprintln(os.Stderr, "Error preparing link card thumbnail:", err)
+		} else if blob, err := uploadBlob(ctx, c, data, mimeType); err != nil {
+			fmt.Fprintln(os.Stderr, "Error uploading link card thumbnail:", err)
+		} else {
+			external.Thumb = blob
+		}
+	}
+
+	return &appbsky.EmbedExternal{External: external}, nil
+}
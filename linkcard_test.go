@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	xrpc "github.com/bluesky-social/indigo/xrpc"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/net/html"
+)
+
+func TestParseLinkMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want linkMetadata
+	}{
+		{
+			name: "open graph",
+			page: `<html><head>
+				<title>Page title</title>
+				<meta property="og:title" content="OG title">
+				<meta property="og:description" content=" OG description ">
+				<meta property="og:image" content="https://example.com/og.png">
+				<meta name="twitter:title" content="Twitter title">
+			</head></html>`,
+			want: linkMetadata{"OG title", "OG description", "https://example.com/og.png"},
+		},
+		{
+			name: "twitter card fallback",
+			page: `<html><head>
+				<meta name="twitter:title" content="Twitter title">
+				<meta name="twitter:description" content="Twitter description">
+				<meta name="twitter:image" content="/twitter.png">
+			</head></html>`,
+			want: linkMetadata{"Twitter title", "Twitter description", "/twitter.png"},
+		},
+		{
+			name: "plain html fallback",
+			page: `<html><head>
+				<title> Page title </title>
+				<meta name="description" content="Meta description">
+			</head></html>`,
+			want: linkMetadata{"Page title", "Meta description", ""},
+		},
+		{
+			name: "first of duplicate properties wins",
+			page: `<html><head>
+				<meta property="OG:TITLE" content="First">
+				<meta property="og:title" content="Second">
+				<meta property="og:image:url" content="https://example.com/url.png">
+			</head></html>`,
+			want: linkMetadata{"First", "", "https://example.com/url.png"},
+		},
+		{
+			name: "empty page",
+			page: ``,
+			want: linkMetadata{},
+		},
+	}
+
+	for _, tt := range tests {
+		doc, err := html.Parse(strings.NewReader(tt.page))
+		if err != nil {
+			t.Fatalf("%s: error parsing page: %s", tt.name, err)
+		}
+		if got := parseLinkMetadata(doc); *got != tt.want {
+			t.Errorf("%s: parseLinkMetadata() = %+v, want %+v", tt.name, *got, tt.want)
+		}
+	}
+}
+
+// testPNG returns a small valid PNG image.
+func testPNG(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 3))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// newLinkServer serves a page whose og:image is relative, reached through a
+// redirect, along with the image it points at.
+func newLinkServer(t *testing.T, pageImage string) *httptest.Server {
+	img := testPNG(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/start", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/articles/post", http.StatusFound)
+	})
+	mux.HandleFunc("/articles/post", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head>
+			<meta property="og:title" content="Title">
+			<meta property="og:description" content="Description">
+			<meta property="og:image" content="` + pageImage + `">
+		</head></html>`))
+	})
+	mux.HandleFunc("/articles/thumb.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(img)
+	})
+	mux.HandleFunc("/big", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("a"), 2048))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testFetcher(srv *httptest.Server) *httpLinkFetcher {
+	return &httpLinkFetcher{client: srv.Client(), maxPageBytes: 1024, maxImageBytes: 1_000_000}
+}
+
+func TestFetchMetadataResolvesRelativeImageAfterRedirect(t *testing.T) {
+	srv := newLinkServer(t, "thumb.png")
+
+	meta, err := testFetcher(srv).FetchMetadata(context.Background(), srv.URL+"/start")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := srv.URL + "/articles/thumb.png"; meta.Image != want {
+		t.Errorf("Image = %q, want %q", meta.Image, want)
+	}
+	if meta.Title != "Title" || meta.Description != "Description" {
+		t.Errorf("got title %q and description %q", meta.Title, meta.Description)
+	}
+}
+
+func TestFetchSizeLimit(t *testing.T) {
+	srv := newLinkServer(t, "thumb.png")
+	f := testFetcher(srv)
+
+	if _, err := f.FetchMetadata(context.Background(), srv.URL+"/big"); err == nil || !strings.Contains(err.Error(), "larger than 1024 bytes") {
+		t.Errorf("FetchMetadata of an oversized page: got error %v", err)
+	}
+	f.maxImageBytes = 10
+	if _, err := f.FetchImage(context.Background(), srv.URL+"/articles/thumb.png"); err == nil {
+		t.Error("FetchImage of an oversized image: got no error")
+	}
+}
+
+// newUploadServer stands in for the PDS's uploadBlob endpoint, counting uploads.
+func newUploadServer(t *testing.T, uploads *int) *xrpc.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/xrpc/com.atproto.repo.uploadBlob" {
+			http.NotFound(w, r)
+			return
+		}
+		*uploads++
+		ref, _ := cid.Decode("bafkreibme22gw2h7y2h7tg2fhqotaqjucnbc24deqo72b6mkl2egezxhvy")
+		json.NewEncoder(w).Encode(comatproto.RepoUploadBlob_Output{
+			Blob: &lexutil.LexBlob{Ref: lexutil.LexLink(ref), MimeType: r.Header.Get("Content-Type"), Size: r.ContentLength},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return &xrpc.Client{Client: srv.Client(), Host: srv.URL}
+}
+
+func TestMakeExternalEmbed(t *testing.T) {
+	srv := newLinkServer(t, "thumb.png")
+	uploads := 0
+	c := newUploadServer(t, &uploads)
+
+	embed, err := makeExternalEmbed(context.Background(), c, testFetcher(srv), srv.URL+"/start")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ext := embed.External
+	if ext.Uri != srv.URL+"/start" || ext.Title != "Title" || ext.Description != "Description" {
+		t.Errorf("got card %+v", ext)
+	}
+	if ext.Thumb == nil || ext.Thumb.MimeType != "image/png" || uploads != 1 {
+		t.Errorf("got thumbnail %+v after %d uploads, want an uploaded PNG", ext.Thumb, uploads)
+	}
+}
+
+func TestMakeExternalEmbedWithBrokenThumbnail(t *testing.T) {
+	srv := newLinkServer(t, "/missing.png")
+	uploads := 0
+	c := newUploadServer(t, &uploads)
+
+	embed, err := makeExternalEmbed(context.Background(), c, testFetcher(srv), srv.URL+"/start")
+	if err != nil {
+		t.Fatalf("a thumbnail that fails to load should not fail the card: %s", err)
+	}
+	if embed.External.Title != "Title" {
+		t.Errorf("Title = %q, want %q", embed.External.Title, "Title")
+	}
+	if embed.External.Thumb != nil || uploads != 0 {
+		t.Errorf("got thumbnail %+v after %d uploads, want none", embed.External.Thumb, uploads)
+	}
+}
+
+func TestMakeExternalEmbedInvalidURL(t *testing.T) {
+	for _, uri := range []string{"ftp://example.com", "example.com", "https://"} {
+		if _, err := makeExternalEmbed(context.Background(), &xrpc.Client{}, defaultLinkFetcher, uri); err == nil {
+			t.Errorf("makeExternalEmbed(%q): got no error", uri)
+		}
+	}
+}
+
+func TestDialPublicOnly(t *testing.T) {
+	tests := []struct {
+		address string
+		ok      bool
+	}{
+		{"93.184.215.14:443", true},
+		{"[2606:2800:21f:cb07:6820:80da:af6b:8b2c]:443", true},
+		{"127.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"10.1.2.3:80", false},
+		{"172.16.0.1:80", false},
+		{"192.168.1.1:80", false},
+		{"169.254.169.254:80", false},
+		{"100.64.0.1:80", false},
+		{"0.0.0.0:80", false},
+		{"[fe80::1]:80", false},
+		{"[fc00::1]:80", false},
+		{"[::ffff:127.0.0.1]:80", false},
+	}
+
+	for _, tt := range tests {
+		if err := dialPublicOnly("tcp", tt.address, nil); (err == nil) != tt.ok {
+			t.Errorf("dialPublicOnly(%q) = %v, want ok %v", tt.address, err, tt.ok)
+		}
+	}
+}
+
+func TestDefaultFetcherRefusesLoopback(t *testing.T) {
+	srv := newLinkServer(t, "thumb.png")
+
+	if _, err := defaultLinkFetcher.FetchMetadata(context.Background(), srv.URL+"/start"); err == nil || !strings.Contains(err.Error(), "non-public address") {
+		t.Errorf("FetchMetadata of a loopback server: got error %v", err)
+	}
+}
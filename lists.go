@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	appbsky "github.com/bluesky-social/indigo/api/bsky"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	xrpc "github.com/bluesky-social/indigo/xrpc"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"saturnvi/bsky-mcp/internal/render"
+)
+
+const (
+	maxListNameBytes            = 64 // the lexicon's maxLength, which counts UTF-8 bytes
+	maxListDescriptionGraphemes = 300
+	maxListDescriptionBytes     = 3000
+	maxListWrites               = 200 // com.atproto.repo.applyWrites limit
+)
+
+// withListAvatar adds the avatar parameter to a list tool.
+func withListAvatar() mcp.ToolOption {
+	return mcp.WithObject("avatar",
+		mcp.Description("Optional avatar image for the list, given as exactly one of a local file path or base64-encoded data. JPEG and PNG are supported; larger images are downscaled to fit the 1MB limit."),
+		mcp.Properties(map[string]any{
+			"path": map[string]any{"type": "string", "description": "Path to a local image file."},
+			"data": map[string]any{"type": "string", "description": "Base64-encoded image data."},
+		}),
+	)
+}
+
+// getListAvatar reads the avatar argument of a request and uploads it. It
+// returns nil if no avatar was given.
+func getListAvatar(ctx context.Context, c *xrpc.Client, request mcp.CallToolRequest) (*lexutil.LexBlob, error) {
+	raw, ok := request.GetArguments()["avatar"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var in imageInput
+	if err := json.Unmarshal(b, &in); err != nil {
+		return nil, fmt.Errorf("avatar must be an object: %w", err)
+	}
+	if (in.Path == "") == (in.Data == "") {
+		return nil, fmt.Errorf("avatar must have exactly one of path or data")
+	}
+
+	data, err := loadImageData(in)
+	if err != nil {
+		return nil, err
+	}
+	data, mimeType, _, err := prepareImage(data, true)
+	if err != nil {
+		return nil, err
+	}
+	if mimeType != "image/jpeg" && mimeType != "image/png" {
+		return nil, fmt.Errorf("unsupported avatar type %s: must be JPEG or PNG", mimeType)
+	}
+	return uploadBlob(ctx, c, data, mimeType)
+}
+
+// checkListText returns an error if name or description are too long for a
+// list.
+func checkListText(name, description string) error {
+	if len(name) > maxListNameBytes {
+		return fmt.Errorf("name is %d bytes long, exceeding the maximum of %d bytes", len(name), maxListNameBytes)
+	}
+	if n := countGraphemes(description); n > maxListDescriptionGraphemes {
+		return fmt.Errorf("description is %d characters long, exceeding the maximum of %d characters", n, maxListDescriptionGraphemes)
+	}
+	if len(description) > maxListDescriptionBytes {
+		return fmt.Errorf("description is %d bytes long, exceeding the maximum of %d bytes", len(description), maxListDescriptionBytes)
+	}
+	return nil
+}
+
+// getOwnList fetches the record of a list owned by the logged in user, along
+// with its CID for swapping.
+func getOwnList(ctx context.Context, c *xrpc.Client, listUri string) (URI, *appbsky.GraphList, string, error) {
+	parsed, err := parseURI(listUri)
+	if err != nil {
+		return URI{}, nil, "", err
+	}
+	if parsed.repo != c.Auth.Did {
+		return URI{}, nil, "", fmt.Errorf("list %s is not yours", listUri)
+	}
+	r, err := comatproto.RepoGetRecord(ctx, c, "", parsed.collection, parsed.repo, parsed.rkey)
+	if err != nil {
+		return URI{}, nil, "", fmt.Errorf("error getting list: %w", err)
+	}
+	var list *appbsky.GraphList
+	if r.Value != nil {
+		list, _ = r.Value.Val.(*appbsky.GraphList)
+	}
+	if list == nil {
+		return URI{}, nil, "", fmt.Errorf("record is not a list: %s", listUri)
+	}
+	return parsed, list, render.StringOrEmpty(r.Cid), nil
+}
+
+// findListItem returns the URI of the list item adding did to a list, or ""
+// if they aren't on it.
+func findListItem(ctx context.Context, c *xrpc.Client, listUri, did string) (string, error) {
+	cursor := ""
+	for {
+		r, err := appbsky.GraphGetList(ctx, c, cursor, 100, listUri)
+		if err != nil {
+			return "", fmt.Errorf("error getting list members: %w", err)
+		}
+		for _, item := range r.Items {
+			if item != nil && item.Subject != nil && item.Subject.Did == did {
+				return item.Uri, nil
+			}
+		}
+		if r.Cursor == nil || *r.Cursor == "" || len(r.Items) == 0 {
+			return "", nil
+		}
+		cursor = *r.Cursor
+	}
+}
+
+// deleteListItems deletes all of the logged in user's list items that belong
+// to listUri, so that deleting a list doesn't leave them behind. It returns
+// how many were deleted.
+func deleteListItems(ctx context.Context, c *xrpc.Client, listUri string) (int, error) {
+	var writes []*comatproto.RepoApplyWrites_Input_Writes_Elem
+	cursor := ""
+	for {
+		r, err := comatproto.RepoListRecords(ctx, c, "app.bsky.graph.listitem", cursor, 100, c.Auth.Did, false)
+		if err != nil {
+			return 0, fmt.Errorf("error listing list items: %w", err)
+		}
+		for _, rec := range r.Records {
+			if rec.Value == nil {
+				continue
+			}
+			item, ok := rec.Value.Val.(*appbsky.GraphListitem)
+			if !ok || item.List != listUri {
+				continue
+			}
+			parsed, err := parseURI(rec.Uri)
+			if err != nil {
+				return 0, err
+			}
+			writes = append(writes, &comatproto.RepoApplyWrites_Input_Writes_Elem{
+				RepoApplyWrites_Delete: &comatproto.RepoApplyWrites_Delete{
+					Collection: parsed.collection,
+					Rkey:       parsed.rkey,
+				},
+			})
+		}
+		if r.Cursor == nil || *r.Cursor == "" || len(r.Records) == 0 {
+			break
+		}
+		cursor = *r.Cursor
+	}
+
+	for i := 0; i < len(writes); i += maxListWrites {
+		batch := writes[i:min(i+maxListWrites, len(writes))]
+		if _, err := comatproto.RepoApplyWrites(ctx, c, &comatproto.RepoApplyWrites_Input{
+			Repo:   c.Auth.Did,
+			Writes: batch,
+		}); err != nil {
+			return i, fmt.Errorf("error deleting list items: %w", err)
+		}
+	}
+	return len(writes), nil
+}
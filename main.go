@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
 
 	comatproto "github.com/bluesky-social/indigo/api/atproto"
 	appbsky "github.com/bluesky-social/indigo/api/bsky"
@@ -16,6 +19,8 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+
+	"saturnvi/bsky-mcp/internal/render"
 )
 
 var Version string = "1.0.0"
@@ -47,13 +52,54 @@ func main() {
 		mcp.WithDescription("Make a Bluesky post"),
 		mcp.WithString("message",
 			mcp.Required(),
-			mcp.Description("The text contents of the post. Maximum length is 300 characters. Mentions (@bsky.app), links (https://google.com), and tags (#example) will be automatically detected and added as facets."),
+			mcp.Description("The text contents of the post. Maximum length is 300 characters (emoji and other combined characters count as one). Mentions (@bsky.app), links (https://google.com), and tags (#example) will be automatically detected and added as facets. Markdown links ([display text](https://google.com)) are posted as just the display text, linked to the url."),
 		),
 		mcp.WithString("replySubject",
-			mcp.Description("Accepts an at-uri. If provided, will create post as a reply top the provided uri."),
+			mcp.Description("Accepts an at-uri or bsky.app link. If provided, will create post as a reply to the provided uri (must be a post)."),
 		),
 		mcp.WithString("repostSubject",
-			mcp.Description("Accepts an at-uri. If provided, will quote post the provided uri (must be a post)."),
+			mcp.Description("Accepts an at-uri or bsky.app link. If provided, will quote post the provided uri (must be a post)."),
+		),
+		mcp.WithArray("images",
+			mcp.Description("Optional images to attach (at most 4). Each image needs alt text and exactly one of a local file path or base64-encoded data. JPEG, PNG, GIF and WebP are supported, with a size limit of 1MB per image."),
+			mcp.MaxItems(maxImages),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"path": map[string]any{"type": "string", "description": "Path to a local image file."},
+					"data": map[string]any{"type": "string", "description": "Base64-encoded image data."},
+					"alt":  map[string]any{"type": "string", "description": "Alt text describing the image."},
+				},
+				"required": []string{"alt"},
+			}),
+		),
+		mcp.WithObject("video",
+			mcp.Description("Optional video to attach. Cannot be combined with images. The video is uploaded to the Bluesky video service and the post is created once processing finishes, which can take a while."),
+			mcp.Properties(map[string]any{
+				"path":   map[string]any{"type": "string", "description": "Path to a local video file (mp4 recommended, up to 100MB)."},
+				"alt":    map[string]any{"type": "string", "description": "Alt text describing the video."},
+				"width":  map[string]any{"type": "number", "description": "Optional width of the video, used for the aspect ratio."},
+				"height": map[string]any{"type": "number", "description": "Optional height of the video, used for the aspect ratio."},
+				"captions": map[string]any{
+					"type":        "array",
+					"description": "Optional WebVTT caption files.",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"lang": map[string]any{"type": "string", "description": "Language of the captions (e.g. en)."},
+							"path": map[string]any{"type": "string", "description": "Path to a local .vtt file."},
+						},
+						"required": []string{"lang", "path"},
+					},
+				},
+			}),
+		),
+		mcp.WithString("linkCard",
+			mcp.Description("Optional url to attach as a link preview card. The page is fetched to get its title, description, and thumbnail. Cannot be combined with images or a video."),
+		),
+		mcp.WithBoolean("downscaleImages",
+			mcp.Description("Whether to downscale and re-encode JPEG and PNG images that are over the size limit before uploading. Default is true."),
+			mcp.DefaultBool(true),
 		),
 	)
 
@@ -62,36 +108,202 @@ func main() {
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
-		if len(m) > 300 {
-			return mcp.NewToolResultError("Message exceeds maximum length of 300 characters"), nil
+		if err := checkPostText(displayText(m)); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Message is too long: %s", err)), nil
+		}
+		images, err := getImageInputs(request)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error reading images: %s", err)), nil
+		}
+		video, err := getVideoInput(request)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error reading video: %s", err)), nil
+		}
+		if video != nil && len(images) > 0 {
+			return mcp.NewToolResultError("A post cannot have both images and a video"), nil
 		}
-		if len(m) == 0 {
+		linkCard := request.GetString("linkCard", "")
+		if linkCard != "" && (video != nil || len(images) > 0) {
+			return mcp.NewToolResultError("A post cannot have both a link card and images or a video"), nil
+		}
+		if len(m) == 0 && len(images) == 0 && video == nil && linkCard == "" {
 			return mcp.NewToolResultError("Message is empty"), nil
 		}
 
-		r, err := createRecord(ctx, c, makePost(ctx, c, m))
+		var reply *appbsky.FeedPost_ReplyRef
+		replySubj, err := getURIParam(ctx, c, request, "replySubject", "app.bsky.feed.post", false)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if replySubj != "" {
+			reply, err = makeReplyRef(ctx, c, replySubj)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error creating reply: %s", err)), nil
+			}
+		}
+
+		var quote *comatproto.RepoStrongRef
+		quoteSubj, err := getURIParam(ctx, c, request, "repostSubject", "app.bsky.feed.post", false)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if quoteSubj != "" {
+			quote, err = makeQuoteRef(ctx, c, quoteSubj)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error creating quote post: %s", err)), nil
+			}
+		}
+
+		var media *appbsky.EmbedRecordWithMedia_Media
+		if len(images) > 0 {
+			embed, err := makeImagesEmbed(ctx, c, images, request.GetBool("downscaleImages", true))
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error attaching images: %s", err)), nil
+			}
+			media = &appbsky.EmbedRecordWithMedia_Media{EmbedImages: embed}
+		}
+		if video != nil {
+			embed, err := makeVideoEmbed(ctx, c, request, video)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error attaching video: %s", err)), nil
+			}
+			media = &appbsky.EmbedRecordWithMedia_Media{EmbedVideo: embed}
+		}
+		if linkCard != "" {
+			embed, err := makeExternalEmbed(ctx, c, defaultLinkFetcher, linkCard)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error creating link card: %s", err)), nil
+			}
+			media = &appbsky.EmbedRecordWithMedia_Media{EmbedExternal: embed}
+		}
+
+		r, err := createRecord(ctx, c, makePost(ctx, c, m, reply, makeEmbed(quote, media)))
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error creating post: %s", err)), nil
 		}
 
-		return mcp.NewToolResultText(fmt.Sprintf("Successfully created post. CID: %s URI: %s", r.Cid, r.Uri)), nil
+		str := "Successfully created post."
+		if reply != nil {
+			str = fmt.Sprintf("Successfully created reply to %s (thread root %s).", reply.Parent.Uri, reply.Root.Uri)
+		}
+		if quote != nil {
+			str += fmt.Sprintf(" Quoted post URI: %s CID: %s.", quote.Uri, quote.Cid)
+		}
+		if len(images) > 0 {
+			str += fmt.Sprintf(" Attached %d image(s).", len(images))
+		}
+		if video != nil {
+			str += " Attached video."
+		}
+		if linkCard != "" {
+			str += fmt.Sprintf(" Attached link card for %s.", linkCard)
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("%s CID: %s URI: %s", str, r.Cid, r.Uri)), nil
+	})
+
+	threadTool := mcp.NewTool("createThread",
+		mcp.WithDescription("Make a Bluesky thread: a chain of posts, each replying to the previous one. All posts are created together, so either the whole thread is posted or none of it is."),
+		mcp.WithArray("segments",
+			mcp.Description("The text of each post in the thread, in order. Each must be at most 300 characters. Mentions, links, tags, and markdown links are detected separately for each post. Either segments or text must be provided."),
+			mcp.Items(map[string]any{"type": "string"}),
+			mcp.MaxItems(maxThreadSegments),
+		),
+		mcp.WithString("text",
+			mcp.Description("Long text to split automatically into a thread, breaking at paragraphs, sentences, or words. Either segments or text must be provided."),
+		),
+		mcp.WithString("replySubject",
+			mcp.Description("Accepts an at-uri or bsky.app link. If provided, the first post of the thread will be a reply to the provided uri (must be a post)."),
+		),
+	)
+
+	s.AddTool(threadTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		segments := request.GetStringSlice("segments", nil)
+		text := request.GetString("text", "")
+		if len(segments) > 0 && text != "" {
+			return mcp.NewToolResultError("Provide either segments or text, not both"), nil
+		}
+		if text != "" {
+			segments = splitThreadText(text, maxPostGraphemes)
+		}
+		if len(segments) == 0 {
+			return mcp.NewToolResultError("Thread is empty"), nil
+		}
+		if len(segments) > maxThreadSegments {
+			return mcp.NewToolResultError(fmt.Sprintf("Thread has %d posts, more than the maximum of %d", len(segments), maxThreadSegments)), nil
+		}
+		for i, seg := range segments {
+			if strings.TrimSpace(seg) == "" {
+				return mcp.NewToolResultError(fmt.Sprintf("Post %d of the thread is empty", i+1)), nil
+			}
+			if err := checkPostText(displayText(seg)); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Post %d of the thread is too long: %s", i+1, err)), nil
+			}
+		}
+
+		var reply *appbsky.FeedPost_ReplyRef
+		replySubj, err := getURIParam(ctx, c, request, "replySubject", "app.bsky.feed.post", false)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if replySubj != "" {
+			reply, err = makeReplyRef(ctx, c, replySubj)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error creating reply: %s", err)), nil
+			}
+		}
+
+		writes, refs, err := makeThreadWrites(ctx, c, segments, reply)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error creating thread: %s", err)), nil
+		}
+		r, err := comatproto.RepoApplyWrites(ctx, c, &comatproto.RepoApplyWrites_Input{
+			Repo:   c.Auth.Did,
+			Writes: writes,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error creating thread: %s", err)), nil
+		}
+
+		str := fmt.Sprintf("Successfully created thread of %d posts.", len(refs))
+		if r.Commit != nil {
+			str += fmt.Sprintf(" Commit CID: %s", r.Commit.Cid)
+		}
+		str += "\n"
+		// the reply refs of later posts were built from CIDs computed locally,
+		// so if the PDS stored a post under a different CID, its replies
+		// point at a version of it that doesn't exist
+		var mismatched []string
+		for i, ref := range refs {
+			if i < len(r.Results) && r.Results[i].RepoApplyWrites_CreateResult != nil {
+				res := r.Results[i].RepoApplyWrites_CreateResult
+				if res.Cid != ref.Cid && i < len(refs)-1 {
+					mismatched = append(mismatched, fmt.Sprintf("post %d (expected %s, got %s)", i+1, ref.Cid, res.Cid))
+				}
+				ref = &comatproto.RepoStrongRef{Cid: res.Cid, Uri: res.Uri}
+			}
+			str += fmt.Sprintf("%d. CID: %s URI: %s\n", i+1, ref.Cid, ref.Uri)
+		}
+		if len(mismatched) > 0 {
+			str += fmt.Sprintf("Warning: the server stored %s under a different CID than was computed for the replies to it, so the thread may not display correctly. Consider deleting the thread and posting it again.\n", strings.Join(mismatched, ", "))
+		}
+		return mcp.NewToolResultText(str), nil
 	})
 
 	repostTool := mcp.NewTool("repost",
 		mcp.WithDescription("Repost a Bluesky post"),
 		mcp.WithString("repostSubject",
 			mcp.Required(),
-			mcp.Description("Accepts an at-uri. Must be a post. Will repost the provided uri."),
+			mcp.Description("Accepts an at-uri or bsky.app link. Must be a post. Will repost the provided uri."),
 		),
 	)
 
 	s.AddTool(repostTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		subj, err := request.RequireString("repostSubject")
+		subj, err := getURIParam(ctx, c, request, "repostSubject", "app.bsky.feed.post", true)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
 
-		repost, err := makeRepost(c, subj)
+		repost, err := makeRepost(ctx, c, subj)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error creating repost: %s", err)), nil
 		}
@@ -102,16 +314,59 @@ func main() {
 		return mcp.NewToolResultText(fmt.Sprintf("Successfully created repost. CID: %s URI: %s", r.Cid, r.Uri)), nil
 	})
 
+	unrepostTool := mcp.NewTool("unrepost",
+		mcp.WithDescription("Undo a repost of a Bluesky post"),
+		mcp.WithString("uri",
+			mcp.Required(),
+			mcp.Description("at-uri or bsky.app link of post to unrepost. must be a post you have reposted."),
+		),
+	)
+
+	s.AddTool(unrepostTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		uri, err := getURIParam(ctx, c, request, "uri", "app.bsky.feed.post", true)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		posts, err := appbsky.FeedGetPosts(ctx, c, []string{uri})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error getting post: %s", err)), nil
+		}
+		if len(posts.Posts) == 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("Post not found: %s", uri)), nil
+		}
+
+		post := posts.Posts[0]
+		if post.Viewer == nil || post.Viewer.Repost == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Post not reposted: %s", uri)), nil
+		}
+		repost := post.Viewer.Repost
+
+		parsed, err := parseURI(*repost)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error parsing repost URI: %s", err)), nil
+		}
+		r, err := comatproto.RepoDeleteRecord(ctx, c, &comatproto.RepoDeleteRecord_Input{
+			Collection: parsed.collection,
+			Repo:       parsed.repo,
+			Rkey:       parsed.rkey,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error deleting repost: %s", err)), nil
+		}
+		return mcp.NewToolResultText("Successfully unreposted post." + render.Commit(r.Commit)), nil
+	})
+
 	deletePostTool := mcp.NewTool("deletePost",
 		mcp.WithDescription("Delete a Bluesky post"),
 		mcp.WithString("uri",
 			mcp.Required(),
-			mcp.Description("at-uri of post to delete. must be your own post."),
+			mcp.Description("at-uri or bsky.app link of post to delete. must be your own post."),
 		),
 	)
 
 	s.AddTool(deletePostTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		uri, err := request.RequireString("uri")
+		uri, err := getURIParam(ctx, c, request, "uri", "app.bsky.feed.post", true)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
@@ -129,19 +384,19 @@ func main() {
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error deleting post: %s", err)), nil
 		}
-		return mcp.NewToolResultText(fmt.Sprintf("Successfully deleted post. Commit CID: %s", r.Commit.Cid)), nil
+		return mcp.NewToolResultText("Successfully deleted post." + render.Commit(r.Commit)), nil
 	})
 
 	likePostTool := mcp.NewTool("likePost",
 		mcp.WithDescription("Like a Bluesky post"),
 		mcp.WithString("uri",
 			mcp.Required(),
-			mcp.Description("at-uri of post to like. must be a post."),
+			mcp.Description("at-uri or bsky.app link of post to like. must be a post."),
 		),
 	)
 
 	s.AddTool(likePostTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		uri, err := request.RequireString("uri")
+		uri, err := getURIParam(ctx, c, request, "uri", "app.bsky.feed.post", true)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
@@ -157,9 +412,10 @@ func main() {
 		}
 
 		like := &appbsky.FeedLike{
-			CreatedAt: syntax.DatetimeNow().String(),
+			LexiconTypeID: "app.bsky.feed.like",
+			CreatedAt:     syntax.DatetimeNow().String(),
 			Subject: &comatproto.RepoStrongRef{
-				Cid: *likeTarget.Cid,
+				Cid: render.StringOrEmpty(likeTarget.Cid),
 				Uri: likeTarget.Uri,
 			},
 		}
@@ -174,19 +430,19 @@ func main() {
 			return mcp.NewToolResultError(fmt.Sprintf("Error liking post: %s", err)), nil
 		}
 
-		return mcp.NewToolResultText(fmt.Sprintf("Successfully liked post. Commit CID: %s", r.Commit.Cid)), nil
+		return mcp.NewToolResultText("Successfully liked post." + render.Commit(r.Commit)), nil
 	})
 
 	unlikePostTool := mcp.NewTool("unlikePost",
 		mcp.WithDescription("Unlike a Bluesky post"),
 		mcp.WithString("uri",
 			mcp.Required(),
-			mcp.Description("at-uri of post to unlike. must be a post."),
+			mcp.Description("at-uri or bsky.app link of post to unlike. must be a post."),
 		),
 	)
 
 	s.AddTool(unlikePostTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		uri, err := request.RequireString("uri")
+		uri, err := getURIParam(ctx, c, request, "uri", "app.bsky.feed.post", true)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
@@ -200,7 +456,7 @@ func main() {
 		}
 
 		post := posts.Posts[0]
-		if post.Viewer.Like == nil {
+		if post.Viewer == nil || post.Viewer.Like == nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Post not liked: %s", uri)), nil
 		}
 		like := post.Viewer.Like
@@ -217,246 +473,1233 @@ func main() {
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error deleting like: %s", err)), nil
 		}
-		return mcp.NewToolResultText(fmt.Sprintf("Successfully unliked post. Commit CID: %s", r.Commit.Cid)), nil
+		return mcp.NewToolResultText("Successfully unliked post." + render.Commit(r.Commit)), nil
 	})
 
 	followUserTool := mcp.NewTool("followUser",
 		mcp.WithDescription("Follow a Bluesky user"),
+		mcp.WithString("actor",
+			mcp.Description(fmt.Sprintf(actorDescription, "user to follow")+" Required unless the deprecated did is given."),
+		),
+		mcp.WithString("did",
+			mcp.Description("Deprecated: use actor instead. DID of the user to follow."),
+		),
+	)
+
+	s.AddTool(followUserTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		did, err := getActorParam(ctx, c, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		follow := &appbsky.GraphFollow{
+			LexiconTypeID: "app.bsky.graph.follow",
+			CreatedAt:     syntax.DatetimeNow().String(),
+			Subject:       did.String(),
+		}
+		r, err := comatproto.RepoCreateRecord(ctx, c, &comatproto.RepoCreateRecord_Input{
+			Collection: follow.LexiconTypeID,
+			Record: &lexutil.LexiconTypeDecoder{
+				Val: follow,
+			},
+			Repo: c.Auth.Did,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error following user: %s", err)), nil
+		}
+
+		return mcp.NewToolResultText("Successfully followed user." + render.Commit(r.Commit)), nil
+	})
+
+	unfollowUserTool := mcp.NewTool("unfollowUser",
+		mcp.WithDescription("Unfollow a Bluesky user"),
+		mcp.WithString("actor",
+			mcp.Description(fmt.Sprintf(actorDescription, "user to unfollow")+" Required unless the deprecated did is given."),
+		),
 		mcp.WithString("did",
+			mcp.Description("Deprecated: use actor instead. DID of the user to unfollow."),
+		),
+	)
+
+	s.AddTool(unfollowUserTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		did, err := getActorParam(ctx, c, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		user, err := appbsky.ActorGetProfile(ctx, c, did.String())
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error getting user profile: %s", err)), nil
+		}
+		if user.Viewer == nil || user.Viewer.Following == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("You are not following user: %s", did)), nil
+		}
+		follow := user.Viewer.Following
+
+		parsed, err := parseURI(*follow)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error parsing follow URI: %s", err)), nil
+		}
+		r, err := comatproto.RepoDeleteRecord(ctx, c, &comatproto.RepoDeleteRecord_Input{
+			Collection: parsed.collection,
+			Repo:       parsed.repo,
+			Rkey:       parsed.rkey,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error deleting follow: %s", err)), nil
+		}
+		return mcp.NewToolResultText("Successfully unfollowed user." + render.Commit(r.Commit)), nil
+	})
+
+	blockUserTool := mcp.NewTool("blockUser",
+		mcp.WithDescription("Block a Bluesky user"),
+		mcp.WithString("actor",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf(actorDescription, "user to block")),
+		),
+	)
+
+	s.AddTool(blockUserTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		did, err := getActorParam(ctx, c, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if did.String() == c.Auth.Did {
+			return mcp.NewToolResultError("You cannot block yourself"), nil
+		}
+
+		user, err := appbsky.ActorGetProfile(ctx, c, did.String())
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error getting user profile: %s", err)), nil
+		}
+		if user.Viewer != nil && user.Viewer.Blocking != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("You are already blocking user: %s (block URI %s)", did, *user.Viewer.Blocking)), nil
+		}
+
+		block := &appbsky.GraphBlock{
+			LexiconTypeID: "app.bsky.graph.block",
+			CreatedAt:     syntax.DatetimeNow().String(),
+			Subject:       did.String(),
+		}
+		r, err := comatproto.RepoCreateRecord(ctx, c, &comatproto.RepoCreateRecord_Input{
+			Collection: block.LexiconTypeID,
+			Record: &lexutil.LexiconTypeDecoder{
+				Val: block,
+			},
+			Repo: c.Auth.Did,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error blocking user: %s", err)), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("Successfully blocked %s. Block URI: %s", render.Actor(user.DisplayName, user.Handle, user.Did), r.Uri)), nil
+	})
+
+	unblockUserTool := mcp.NewTool("unblockUser",
+		mcp.WithDescription("Unblock a Bluesky user"),
+		mcp.WithString("actor",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf(actorDescription, "user to unblock")),
+		),
+	)
+
+	s.AddTool(unblockUserTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		did, err := getActorParam(ctx, c, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		user, err := appbsky.ActorGetProfile(ctx, c, did.String())
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error getting user profile: %s", err)), nil
+		}
+		if user.Viewer == nil || user.Viewer.Blocking == nil {
+			if user.Viewer != nil && user.Viewer.BlockingByList != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("User %s is blocked by a moderation list you subscribe to (%s), not by you directly", did, user.Viewer.BlockingByList.Uri)), nil
+			}
+			return mcp.NewToolResultError(fmt.Sprintf("You are not blocking user: %s", did)), nil
+		}
+
+		parsed, err := parseURI(*user.Viewer.Blocking)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error parsing block URI: %s", err)), nil
+		}
+		r, err := comatproto.RepoDeleteRecord(ctx, c, &comatproto.RepoDeleteRecord_Input{
+			Collection: parsed.collection,
+			Repo:       parsed.repo,
+			Rkey:       parsed.rkey,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error deleting block: %s", err)), nil
+		}
+		return mcp.NewToolResultText("Successfully unblocked user." + render.Commit(r.Commit)), nil
+	})
+
+	listBlocksTool := mcp.NewTool("listBlocks",
+		mcp.WithDescription("Lists the users you are blocking."),
+		mcp.WithString("cursor",
+			mcp.Description("Optional cursor to paginate through blocks. If not provided, will read the most recent blocks."),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Optional limit on the number of blocks to read. Possible values: >= 1 and <= 100. Default is 50."),
+		),
+		withOutputFormat(),
+		mcp.WithOutputSchema[actorsOutput](),
+	)
+
+	s.AddTool(listBlocksTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		cursorParam := request.GetString("cursor", "")
+		limit := request.GetInt("limit", 50)
+
+		r, err := appbsky.GraphGetBlocks(ctx, c, cursorParam, int64(limit))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error getting blocks: %s", err)), nil
+		}
+
+		str := fmt.Sprintf("%d blocked users (cursor: %s):\n", len(r.Blocks), render.CursorOrEmpty(r.Cursor))
+		for _, actor := range r.Blocks {
+			str += render.ProfileView(actor) + "\n"
+		}
+
+		out := actorsOutput{Cursor: render.CursorOrEmpty(r.Cursor), Actors: actorsFromViews(r.Blocks)}
+		return formatResult(request, out, str), nil
+	})
+
+	muteUserTool := mcp.NewTool("muteUser",
+		mcp.WithDescription("Mute a Bluesky user. Mutes are private: their posts are hidden from you without them being told."),
+		mcp.WithString("actor",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf(actorDescription, "user to mute")),
+		),
+	)
+
+	s.AddTool(muteUserTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		did, err := getActorParam(ctx, c, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if did.String() == c.Auth.Did {
+			return mcp.NewToolResultError("You cannot mute yourself"), nil
+		}
+
+		if err := appbsky.GraphMuteActor(ctx, c, &appbsky.GraphMuteActor_Input{Actor: did.String()}); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error muting user: %s", err)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Successfully muted user: %s", did)), nil
+	})
+
+	unmuteUserTool := mcp.NewTool("unmuteUser",
+		mcp.WithDescription("Unmute a Bluesky user"),
+		mcp.WithString("actor",
 			mcp.Required(),
-			mcp.Description("DID of the user to follow. Must be a valid Bluesky DID (e.g., did:plc:... or did:web:...)."),
+			mcp.Description(fmt.Sprintf(actorDescription, "user to unmute")),
 		),
 	)
 
-	s.AddTool(followUserTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		did, err := request.RequireString("did")
+	s.AddTool(unmuteUserTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		did, err := getActorParam(ctx, c, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		if err := appbsky.GraphUnmuteActor(ctx, c, &appbsky.GraphUnmuteActor_Input{Actor: did.String()}); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error unmuting user: %s", err)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Successfully unmuted user: %s", did)), nil
+	})
+
+	listMutesTool := mcp.NewTool("listMutes",
+		mcp.WithDescription("Lists the users you have muted."),
+		mcp.WithString("cursor",
+			mcp.Description("Optional cursor to paginate through mutes. If not provided, will read the most recent mutes."),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Optional limit on the number of mutes to read. Possible values: >= 1 and <= 100. Default is 50."),
+		),
+		withOutputFormat(),
+		mcp.WithOutputSchema[actorsOutput](),
+	)
+
+	s.AddTool(listMutesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		cursorParam := request.GetString("cursor", "")
+		limit := request.GetInt("limit", 50)
+
+		r, err := appbsky.GraphGetMutes(ctx, c, cursorParam, int64(limit))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error getting mutes: %s", err)), nil
+		}
+
+		str := fmt.Sprintf("%d muted users (cursor: %s):\n", len(r.Mutes), render.CursorOrEmpty(r.Cursor))
+		for _, actor := range r.Mutes {
+			str += render.ProfileView(actor) + "\n"
+		}
+
+		out := actorsOutput{Cursor: render.CursorOrEmpty(r.Cursor), Actors: actorsFromViews(r.Mutes)}
+		return formatResult(request, out, str), nil
+	})
+
+	muteThreadTool := mcp.NewTool("muteThread",
+		mcp.WithDescription("Mute a thread, so that you stop getting notifications from it. Any post in the thread can be given."),
+		mcp.WithString("uri",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf(uriDescription, "a post in the thread to mute")),
+		),
+	)
+
+	s.AddTool(muteThreadTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		uri, err := getURIParam(ctx, c, request, "uri", "app.bsky.feed.post", true)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		root, err := muteThreadRoot(ctx, c, uri)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		if err := appbsky.GraphMuteThread(ctx, c, &appbsky.GraphMuteThread_Input{Root: root}); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error muting thread: %s", err)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Successfully muted thread with root post: %s", root)), nil
+	})
+
+	unmuteThreadTool := mcp.NewTool("unmuteThread",
+		mcp.WithDescription("Unmute a thread. Any post in the thread can be given."),
+		mcp.WithString("uri",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf(uriDescription, "a post in the thread to unmute")),
+		),
+	)
+
+	s.AddTool(unmuteThreadTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		uri, err := getURIParam(ctx, c, request, "uri", "app.bsky.feed.post", true)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		root, err := muteThreadRoot(ctx, c, uri)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		if err := appbsky.GraphUnmuteThread(ctx, c, &appbsky.GraphUnmuteThread_Input{Root: root}); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error unmuting thread: %s", err)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Successfully unmuted thread with root post: %s", root)), nil
+	})
+
+	listMutedWordsTool := mcp.NewTool("listMutedWords",
+		mcp.WithDescription("Lists your muted words and tags."),
+		withOutputFormat(),
+		mcp.WithOutputSchema[mutedWordsOutput](),
+	)
+
+	s.AddTool(listMutedWordsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		words, err := getMutedWords(ctx, c)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		str := fmt.Sprintf("%d muted words:\n", len(words))
+		for _, w := range words {
+			if w != nil {
+				str += "- " + render.MutedWord(w) + "\n"
+			}
+		}
+
+		return formatResult(request, mutedWordsFromAPI(words), str), nil
+	})
+
+	addMutedWordTool := mcp.NewTool("addMutedWord",
+		mcp.WithDescription("Mute a word, phrase or tag. If it is already muted, its options are replaced with the given ones."),
+		mcp.WithString("value",
+			mcp.Required(),
+			mcp.Description("The word, phrase or tag to mute. A leading # is removed."),
+		),
+		mcp.WithArray("targets",
+			mcp.Description("Optional list of where to match the word: 'content' for post text, 'tag' for tags. Default is both."),
+			mcp.Items(map[string]any{"type": "string", "enum": []string{"content", "tag"}}),
+		),
+		mcp.WithBoolean("excludeFollowing",
+			mcp.Description("Whether to still show posts from users you follow that contain the word. Default is false."),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithNumber("expiresInHours",
+			mcp.Description("Optional number of hours after which the word is unmuted. If not provided, it is muted until removed."),
+		),
+	)
+
+	s.AddTool(addMutedWordTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		value, err := request.RequireString("value")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		value = normalizeMutedWord(value)
+		if value == "" {
+			return mcp.NewToolResultError("The muted word cannot be empty"), nil
+		}
+
+		word := &appbsky.ActorDefs_MutedWord{Value: value}
+		targets := request.GetStringSlice("targets", []string{"content", "tag"})
+		if len(targets) == 0 {
+			return mcp.NewToolResultError("At least one target is required"), nil
+		}
+		for _, t := range slices.Compact(slices.Sorted(slices.Values(targets))) {
+			if t != "content" && t != "tag" {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid target %q: must be 'content' or 'tag'", t)), nil
+			}
+			word.Targets = append(word.Targets, &t)
+		}
+		actorTarget := "all"
+		if request.GetBool("excludeFollowing", false) {
+			actorTarget = "exclude-following"
+		}
+		word.ActorTarget = &actorTarget
+		if hours := request.GetFloat("expiresInHours", 0); hours > 0 {
+			expiresAt := mutedWordExpiry(hours)
+			word.ExpiresAt = &expiresAt
+		} else if hours < 0 {
+			return mcp.NewToolResultError("expiresInHours must be positive"), nil
+		}
+
+		word, updated, err := upsertMutedWord(ctx, c, word)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error muting word: %s", err)), nil
+		}
+		if updated {
+			return mcp.NewToolResultText("Successfully updated muted word " + render.MutedWord(word)), nil
+		}
+		return mcp.NewToolResultText("Successfully muted word " + render.MutedWord(word)), nil
+	})
+
+	removeMutedWordTool := mcp.NewTool("removeMutedWord",
+		mcp.WithDescription("Unmute a muted word, phrase or tag."),
+		mcp.WithString("value",
+			mcp.Required(),
+			mcp.Description("The muted word, or its ID as returned by listMutedWords."),
+		),
+	)
+
+	s.AddTool(removeMutedWordTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		value, err := request.RequireString("value")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		if err := removeMutedWord(ctx, c, value); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error removing muted word: %s", err)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Successfully unmuted word: %s", value)), nil
+	})
+
+	notificationTool := mcp.NewTool("readNotifications",
+		mcp.WithDescription("Reads notifications"),
+		mcp.WithString("cursor",
+			mcp.Description("Optional cursor to paginate through notifications. If not provided, will read the latest notifications."),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Optional limit on the number of notifications to read. Default is 50."),
+		),
+		mcp.WithArray("reasons",
+			mcp.Description("Optional list of notification reasons to include. If not provided, all notifications are included."),
+			mcp.Items(map[string]any{"type": "string", "enum": notificationReasons}),
+		),
+		mcp.WithBoolean("onlyUnread",
+			mcp.Description("Whether to only include notifications that haven't been marked as seen. Default is false."),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithString("seenAt",
+			mcp.Description("Optional timestamp (e.g. 2024-01-02T15:04:05Z) to treat as the last time notifications were seen, instead of the one stored on the server."),
+		),
+		withOutputFormat(),
+		mcp.WithOutputSchema[notificationsOutput](),
+	)
+
+	s.AddTool(notificationTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		cursor := request.GetString("cursor", "")
+		limit := request.GetInt("limit", 50)
+		reasons := request.GetStringSlice("reasons", nil)
+		onlyUnread := request.GetBool("onlyUnread", false)
+		seenAt := request.GetString("seenAt", "")
+		if seenAt != "" {
+			if _, err := syntax.ParseDatetime(seenAt); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid seenAt timestamp: %s", err)), nil
+			}
+		}
+
+		r, err := appbsky.NotificationListNotifications(ctx, c, cursor, int64(limit), false, reasons, seenAt)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error reading notifications: %s", err)), nil
+		}
+
+		notifications := r.Notifications
+		if onlyUnread {
+			notifications = slices.DeleteFunc(slices.Clone(notifications), func(n *appbsky.NotificationListNotifications_Notification) bool {
+				return n.IsRead
+			})
+		}
+
+		str := fmt.Sprintf("%d notifications (cursor: %s):\n", len(notifications), render.CursorOrEmpty(r.Cursor))
+		if r.SeenAt != nil {
+			str += fmt.Sprintf("Notifications last seen at %s\n", *r.SeenAt)
+		}
+		if len(notifications) > 0 {
+			// newest first, so this is what to pass to markNotificationsSeen
+			str += fmt.Sprintf("Newest notification indexed at %s\n", notifications[0].IndexedAt)
+		}
+		pc := hydrateNotificationSubjects(ctx, c, notifications)
+		str += render.Notifications(pc, notifications)
+		return formatResult(request, notificationsFromAPI(r, notifications, pc), str), nil
+	})
+
+	unreadCountTool := mcp.NewTool("getUnreadCount",
+		mcp.WithDescription("Gets the number of unread notifications"),
+		mcp.WithString("seenAt",
+			mcp.Description("Optional timestamp (e.g. 2024-01-02T15:04:05Z) to count notifications after, instead of the last time notifications were seen."),
+		),
+		withOutputFormat(),
+		mcp.WithOutputSchema[unreadCountOutput](),
+	)
+
+	s.AddTool(unreadCountTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		seenAt := request.GetString("seenAt", "")
+		if seenAt != "" {
+			if _, err := syntax.ParseDatetime(seenAt); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid seenAt timestamp: %s", err)), nil
+			}
+		}
+
+		r, err := appbsky.NotificationGetUnreadCount(ctx, c, false, seenAt)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error getting unread count: %s", err)), nil
+		}
+
+		str := fmt.Sprintf("%d unread notifications", r.Count)
+		return formatResult(request, unreadCountOutput{Count: r.Count}, str), nil
+	})
+
+	markSeenTool := mcp.NewTool("markNotificationsSeen",
+		mcp.WithDescription("Marks notifications as seen up to a given time"),
+		mcp.WithString("seenAt",
+			mcp.Description("Optional timestamp (e.g. 2024-01-02T15:04:05Z) to mark notifications as seen up to. Use the newest notification's timestamp from readNotifications to avoid skipping ones that arrived since. Default is now."),
+		),
+	)
+
+	s.AddTool(markSeenTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		seenAt := request.GetString("seenAt", "")
+		if seenAt == "" {
+			seenAt = syntax.DatetimeNow().String()
+		} else if _, err := syntax.ParseDatetime(seenAt); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid seenAt timestamp: %s", err)), nil
+		}
+
+		err := appbsky.NotificationUpdateSeen(ctx, c, &appbsky.NotificationUpdateSeen_Input{
+			SeenAt: seenAt,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error marking notifications as seen: %s", err)), nil
+		}
+
+		str := fmt.Sprintf("Marked notifications as seen up to %s", seenAt)
+		return mcp.NewToolResultText(str), nil
+	})
+
+	readThreadTool := mcp.NewTool("readThread",
+		mcp.WithDescription("Reads a post along with its parents and nested replies."),
+		mcp.WithString("uri",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf(uriDescription, "post to read the thread of")),
+		),
+		mcp.WithNumber("depth",
+			mcp.Description("How many levels of replies to include. Default is 6."),
+			mcp.DefaultNumber(6),
+			mcp.Min(0),
+			mcp.Max(1000),
+		),
+		mcp.WithNumber("parentHeight",
+			mcp.Description("How many parent posts to include. Default is 80."),
+			mcp.DefaultNumber(80),
+			mcp.Min(0),
+			mcp.Max(1000),
+		),
+		withIncludeImages(),
+		withOutputFormat(),
+		mcp.WithOutputSchema[threadOutput](),
+	)
+
+	s.AddTool(readThreadTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		uri, err := getURIParam(ctx, c, request, "uri", "app.bsky.feed.post", true)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		depth := request.GetInt("depth", 6)
+		parentHeight := request.GetInt("parentHeight", 80)
+
+		r, err := appbsky.FeedGetPostThread(ctx, c, int64(depth), int64(parentHeight), uri)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error reading thread: %s", err)), nil
+		}
+		if r.Thread == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Thread not found: %s", uri)), nil
+		}
+
+		str := render.Thread(r.Thread, c.Auth.Did)
+		out := threadFromAPI(r.Thread, c.Auth.Did)
+		return withImages(ctx, request, formatResult(request, out, str), out.posts()), nil
+	})
+
+	getPostsTool := mcp.NewTool("getPosts",
+		mcp.WithDescription("Gets the current content and counts of posts given their at-uris."),
+		mcp.WithArray("uris",
+			mcp.Required(),
+			mcp.Description("at-uris or bsky.app links of the posts to get."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		withIncludeImages(),
+		withOutputFormat(),
+		mcp.WithOutputSchema[feedOutput](),
+	)
+
+	s.AddTool(getPostsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		rawUris, err := request.RequireStringSlice("uris")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if len(rawUris) == 0 {
+			return mcp.NewToolResultError("No URIs provided"), nil
+		}
+		// URIs that can't be normalized are reported as missing along with
+		// the rest, rather than failing the whole lookup. Different links to
+		// the same post are only looked up and counted once.
+		var uris, missing []string
+		invalid := map[string]error{}
+		for _, raw := range rawUris {
+			uri, err := normalizeURI(ctx, c, raw, "app.bsky.feed.post")
+			if err != nil {
+				if _, ok := invalid[raw]; !ok {
+					missing = append(missing, raw)
+					invalid[raw] = err
+				}
+				continue
+			}
+			if !slices.Contains(uris, uri) {
+				uris = append(uris, uri)
+			}
+		}
+
+		posts, err := getPosts(ctx, c, uris)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error getting posts: %s", err)), nil
+		}
+
+		str := fmt.Sprintf("Found %d of %d posts:\n", len(posts), len(uris)+len(invalid))
+		str += render.PostViews(&posts)
+
+		for _, uri := range uris {
+			if !slices.ContainsFunc(posts, func(p *appbsky.FeedDefs_PostView) bool { return p.Uri == uri }) {
+				missing = append(missing, uri)
+			}
+		}
+		if len(missing) > 0 {
+			str += "Could not find (deleted, blocked, or invalid):\n"
+			for _, uri := range missing {
+				if err, ok := invalid[uri]; ok {
+					str += fmt.Sprintf("- %s (%s)\n", uri, err)
+				} else {
+					str += fmt.Sprintf("- %s\n", uri)
+				}
+			}
+		}
+
+		out := feedFromPostViews("", posts)
+		out.Missing = missing
+		return withImages(ctx, request, formatResult(request, out, str), out.Posts), nil
+	})
+
+	getLikesTool := mcp.NewTool("getLikes",
+		mcp.WithDescription("Gets the users who liked a post."),
+		mcp.WithString("uri",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf(uriDescription, "post")),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("Optional cursor to paginate through likes. If not provided, will read the latest likes."),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Optional limit on the number of likes to read. Possible values: >= 1 and <= 100. Default is 50."),
+		),
+		withOutputFormat(),
+		mcp.WithOutputSchema[likesOutput](),
+	)
+
+	s.AddTool(getLikesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		uri, err := getURIParam(ctx, c, request, "uri", "app.bsky.feed.post", true)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		cursorParam := request.GetString("cursor", "")
+		limit := request.GetInt("limit", 50)
+
+		r, err := appbsky.FeedGetLikes(ctx, c, "", cursorParam, int64(limit), uri)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error getting likes: %s", err)), nil
+		}
+
+		str := fmt.Sprintf("%d users liked post %s (cursor: %s):\n", len(r.Likes), r.Uri, render.CursorOrEmpty(r.Cursor))
+		out := likesOutput{Uri: r.Uri, Cursor: render.CursorOrEmpty(r.Cursor), Likes: []likeOutput{}}
+		for _, like := range r.Likes {
+			if like.Actor == nil {
+				continue
+			}
+			str += fmt.Sprintf("%s, liked at %s\n", render.Actor(like.Actor.DisplayName, like.Actor.Handle, like.Actor.Did), like.CreatedAt)
+			out.Likes = append(out.Likes, likeOutput{Actor: actorFromView(like.Actor), CreatedAt: like.CreatedAt})
+		}
+
+		return formatResult(request, out, str), nil
+	})
+
+	getRepostedByTool := mcp.NewTool("getRepostedBy",
+		mcp.WithDescription("Gets the users who reposted a post."),
+		mcp.WithString("uri",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf(uriDescription, "post")),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("Optional cursor to paginate through reposts. If not provided, will read the latest reposts."),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Optional limit on the number of reposts to read. Possible values: >= 1 and <= 100. Default is 50."),
+		),
+		withOutputFormat(),
+		mcp.WithOutputSchema[actorsOutput](),
+	)
+
+	s.AddTool(getRepostedByTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		uri, err := getURIParam(ctx, c, request, "uri", "app.bsky.feed.post", true)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		cursorParam := request.GetString("cursor", "")
+		limit := request.GetInt("limit", 50)
+
+		r, err := appbsky.FeedGetRepostedBy(ctx, c, "", cursorParam, int64(limit), uri)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error getting reposts: %s", err)), nil
+		}
+
+		str := fmt.Sprintf("%d users reposted post %s (cursor: %s):\n", len(r.RepostedBy), r.Uri, render.CursorOrEmpty(r.Cursor))
+		for _, actor := range r.RepostedBy {
+			str += render.ProfileView(actor) + "\n"
+		}
+
+		out := actorsOutput{Cursor: render.CursorOrEmpty(r.Cursor), Actors: actorsFromViews(r.RepostedBy)}
+		return formatResult(request, out, str), nil
+	})
+
+	getQuotesTool := mcp.NewTool("getQuotes",
+		mcp.WithDescription("Gets the posts quoting a post."),
+		mcp.WithString("uri",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf(uriDescription, "post")),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("Optional cursor to paginate through quotes. If not provided, will read the latest quotes."),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Optional limit on the number of quotes to read. Possible values: >= 1 and <= 100. Default is 50."),
+		),
+		withIncludeImages(),
+		withOutputFormat(),
+		mcp.WithOutputSchema[feedOutput](),
+	)
+
+	s.AddTool(getQuotesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		uri, err := getURIParam(ctx, c, request, "uri", "app.bsky.feed.post", true)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		cursorParam := request.GetString("cursor", "")
+		limit := request.GetInt("limit", 50)
+
+		r, err := appbsky.FeedGetQuotes(ctx, c, "", cursorParam, int64(limit), uri)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error getting quotes: %s", err)), nil
+		}
+
+		str := fmt.Sprintf("%d posts quoted post %s (cursor: %s):\n", len(r.Posts), r.Uri, render.CursorOrEmpty(r.Cursor))
+		str += render.PostViews(&r.Posts)
+
+		out := feedFromPostViews(render.CursorOrEmpty(r.Cursor), r.Posts)
+		return withImages(ctx, request, formatResult(request, out, str), out.Posts), nil
+	})
+
+	readFeedTool := mcp.NewTool("readFeed",
+		mcp.WithDescription("Reads a feed."),
+		mcp.WithString("feedUri",
+			mcp.Description("Optional at-uri or bsky.app link of the feed to read. If a feed URI is not provided, it will read the home feed (Following)."),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("Optional cursor to paginate through posts. If not provided, will read the latest posts."),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Optional limit on the number of posts to read. Default is 50."),
+		),
+		withIncludeImages(),
+		withOutputFormat(),
+		mcp.WithOutputSchema[feedOutput](),
+	)
+
+	s.AddTool(readFeedTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		feedUri, err := getURIParam(ctx, c, request, "feedUri", "app.bsky.feed.generator", false)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		cursorParam := request.GetString("cursor", "")
+		limit := request.GetInt("limit", 50)
+		var posts []*appbsky.FeedDefs_FeedViewPost
+		var cursor string = ""
+
+		feedName := "Following"
+		if feedUri == "" {
+			// there's a builtin getTimeline
+			r, err := appbsky.FeedGetTimeline(ctx, c, "", cursorParam, int64(limit))
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error reading home feed: %s", err)), nil
+			}
+			posts = r.Feed
+			if r.Cursor != nil {
+				cursor = *r.Cursor
+			}
+		} else {
+			// read a specific feed
+			r, err := appbsky.FeedGetFeed(ctx, c, cursorParam, feedUri, int64(limit))
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error reading home feed: %s", err)), nil
+			}
+			posts = r.Feed
+			if r.Cursor != nil {
+				cursor = *r.Cursor
+			}
+
+			feedName = feedUri
+			if gen, err := appbsky.FeedGetFeedGenerator(ctx, c, feedUri); err == nil && gen.View != nil {
+				feedName = gen.View.DisplayName
+			}
+		}
+
+		str := fmt.Sprintf("\"%s\" Feed (cursor: %s):\n", feedName, cursor)
+
+		str += render.Posts(posts)
+
+		out := feedFromFeedViews(feedName, cursor, posts)
+		return withImages(ctx, request, formatResult(request, out, str), out.Posts), nil
+	})
+
+	readListFeedTool := mcp.NewTool("readListFeed",
+		mcp.WithDescription("Reads a feed."),
+		mcp.WithString("listUri",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf(uriDescription, "list to get the feed of")),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("Optional cursor to paginate through posts. If not provided, will read the latest posts."),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Optional limit on the number of posts to read. Default is 50."),
+		),
+		withIncludeImages(),
+		withOutputFormat(),
+		mcp.WithOutputSchema[feedOutput](),
+	)
+
+	s.AddTool(readListFeedTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		listUri, err := getURIParam(ctx, c, request, "listUri", "app.bsky.graph.list", true)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		cursorParam := request.GetString("cursor", "")
+		limit := request.GetInt("limit", 50)
+		var posts []*appbsky.FeedDefs_FeedViewPost
+		var cursor string = ""
+
+		l, err := appbsky.GraphGetList(ctx, c, cursorParam, int64(limit), listUri)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error getting list: %s", err)), nil
+		}
+		listName := l.List.Name
+
+		r, err := appbsky.FeedGetListFeed(ctx, c, cursorParam, int64(limit), listUri)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error reading list feed: %s", err)), nil
+		}
+		if r.Cursor != nil {
+			cursor = *r.Cursor
+		}
+		posts = r.Feed
+
+		str := fmt.Sprintf("Feed generated from list \"%s\" (cursor: %s):\n", listName, cursor)
+		str += render.Posts(posts)
+
+		out := feedFromFeedViews(listName, cursor, posts)
+		return withImages(ctx, request, formatResult(request, out, str), out.Posts), nil
+	})
+
+	createListTool := mcp.NewTool("createList",
+		mcp.WithDescription("Create a list of users. Curation lists can be read as a feed with readListFeed; moderation lists can be subscribed to in order to mute or block everyone on them."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf("Name of the list. Maximum length is %d bytes, so fewer characters for non-ASCII text.", maxListNameBytes)),
+		),
+		mcp.WithString("purpose",
+			mcp.Required(),
+			mcp.Description("'curate' for a curation list, or 'modlist' for a moderation list."),
+			mcp.Enum("curate", "modlist"),
+		),
+		mcp.WithString("description",
+			mcp.Description(fmt.Sprintf("Optional description of the list. Maximum length is %d characters. Mentions, links, and tags will be automatically detected and added as facets.", maxListDescriptionGraphemes)),
+		),
+		withListAvatar(),
+	)
+
+	s.AddTool(createListTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := request.RequireString("name")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return mcp.NewToolResultError("List name cannot be empty"), nil
+		}
+		purpose, ok := render.ListPurposes[request.GetString("purpose", "")]
+		if !ok {
+			return mcp.NewToolResultError("purpose must be 'curate' or 'modlist'"), nil
+		}
+		description := request.GetString("description", "")
+		if err := checkListText(name, displayText(description)); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		list := &appbsky.GraphList{
+			LexiconTypeID: "app.bsky.graph.list",
+			CreatedAt:     syntax.DatetimeNow().String(),
+			Name:          name,
+			Purpose:       &purpose,
+		}
+		if description != "" {
+			text, facets := getFacetsFromString(ctx, c, description)
+			list.Description = &text
+			list.DescriptionFacets = facets
+		}
+		list.Avatar, err = getListAvatar(ctx, c, request)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error uploading avatar: %s", err)), nil
+		}
+
+		r, err := comatproto.RepoCreateRecord(ctx, c, &comatproto.RepoCreateRecord_Input{
+			Collection: list.LexiconTypeID,
+			Record: &lexutil.LexiconTypeDecoder{
+				Val: list,
+			},
+			Repo: c.Auth.Did,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error creating list: %s", err)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Successfully created list %q. List URI: %s", name, r.Uri)), nil
+	})
+
+	updateListTool := mcp.NewTool("updateList",
+		mcp.WithDescription("Update the name, description or avatar of one of your lists. Only the given fields are changed."),
+		mcp.WithString("listUri",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf(uriDescription, "list to update")),
+		),
+		mcp.WithString("name",
+			mcp.Description(fmt.Sprintf("Optional new name of the list. Maximum length is %d bytes, so fewer characters for non-ASCII text.", maxListNameBytes)),
+		),
+		mcp.WithString("description",
+			mcp.Description(fmt.Sprintf("Optional new description of the list, or an empty string to remove it. Maximum length is %d characters. Mentions, links, and tags will be automatically detected and added as facets.", maxListDescriptionGraphemes)),
+		),
+		withListAvatar(),
+		mcp.WithBoolean("removeAvatar",
+			mcp.Description("Whether to remove the list's avatar. Default is false."),
+			mcp.DefaultBool(false),
+		),
+	)
+
+	s.AddTool(updateListTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		listUri, err := getURIParam(ctx, c, request, "listUri", "app.bsky.graph.list", true)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		parsed, list, cid, err := getOwnList(ctx, c, listUri)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		args := request.GetArguments()
+		if _, ok := args["name"]; ok {
+			list.Name = strings.TrimSpace(request.GetString("name", ""))
+			if list.Name == "" {
+				return mcp.NewToolResultError("List name cannot be empty"), nil
+			}
+		}
+		if _, ok := args["description"]; ok {
+			list.Description, list.DescriptionFacets = nil, nil
+			if description := request.GetString("description", ""); description != "" {
+				text, facets := getFacetsFromString(ctx, c, description)
+				list.Description = &text
+				list.DescriptionFacets = facets
+			}
+		}
+		if err := checkListText(list.Name, render.StringOrEmpty(list.Description)); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if request.GetBool("removeAvatar", false) {
+			list.Avatar = nil
+		}
+		avatar, err := getListAvatar(ctx, c, request)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error uploading avatar: %s", err)), nil
+		}
+		if avatar != nil {
+			list.Avatar = avatar
+		}
+
+		input := &comatproto.RepoPutRecord_Input{
+			Collection: parsed.collection,
+			Record: &lexutil.LexiconTypeDecoder{
+				Val: list,
+			},
+			Repo: parsed.repo,
+			Rkey: parsed.rkey,
+		}
+		// only swap against the record we read if the PDS told us its CID
+		if cid != "" {
+			input.SwapRecord = &cid
+		}
+		r, err := comatproto.RepoPutRecord(ctx, c, input)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error updating list: %s", err)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Successfully updated list %q. List URI: %s", list.Name, r.Uri)), nil
+	})
+
+	deleteListTool := mcp.NewTool("deleteList",
+		mcp.WithDescription("Delete one of your lists, along with its members."),
+		mcp.WithString("listUri",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf(uriDescription, "list to delete")),
+		),
+	)
+
+	s.AddTool(deleteListTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		listUri, err := getURIParam(ctx, c, request, "listUri", "app.bsky.graph.list", true)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		parsed, list, _, err := getOwnList(ctx, c, listUri)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		n, err := deleteListItems(ctx, c, listUri)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error deleting list members (%d deleted): %s", n, err)), nil
+		}
+		if _, err := comatproto.RepoDeleteRecord(ctx, c, &comatproto.RepoDeleteRecord_Input{
+			Collection: parsed.collection,
+			Repo:       parsed.repo,
+			Rkey:       parsed.rkey,
+		}); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error deleting list: %s", err)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Successfully deleted list %q and its %d members", list.Name, n)), nil
+	})
+
+	addToListTool := mcp.NewTool("addToList",
+		mcp.WithDescription("Add a user to one of your lists."),
+		mcp.WithString("listUri",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf(uriDescription, "list to add the user to")),
+		),
+		mcp.WithString("actor",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf(actorDescription, "user to add")),
+		),
+	)
+
+	s.AddTool(addToListTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		listUri, err := getURIParam(ctx, c, request, "listUri", "app.bsky.graph.list", true)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		did, err := getActorParam(ctx, c, request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		_, list, _, err := getOwnList(ctx, c, listUri)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		existing, err := findListItem(ctx, c, listUri, did.String())
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
+		if existing != "" {
+			return mcp.NewToolResultError(fmt.Sprintf("User %s is already on list %q (list item URI %s)", did, list.Name, existing)), nil
+		}
 
-		follow := &appbsky.GraphFollow{
-			CreatedAt: syntax.DatetimeNow().String(),
-			Subject:   did,
+		item := &appbsky.GraphListitem{
+			LexiconTypeID: "app.bsky.graph.listitem",
+			CreatedAt:     syntax.DatetimeNow().String(),
+			List:          listUri,
+			Subject:       did.String(),
 		}
 		r, err := comatproto.RepoCreateRecord(ctx, c, &comatproto.RepoCreateRecord_Input{
-			Collection: follow.LexiconTypeID,
+			Collection: item.LexiconTypeID,
 			Record: &lexutil.LexiconTypeDecoder{
-				Val: follow,
+				Val: item,
 			},
 			Repo: c.Auth.Did,
 		})
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Error following user: %s", err)), nil
+			return mcp.NewToolResultError(fmt.Sprintf("Error adding user to list: %s", err)), nil
 		}
-
-		return mcp.NewToolResultText(fmt.Sprintf("Successfully followed user. Commit CID: %s", r.Commit.Cid)), nil
+		return mcp.NewToolResultText(fmt.Sprintf("Successfully added %s to list %q. List item URI: %s", did, list.Name, r.Uri)), nil
 	})
 
-	unfollowUserTool := mcp.NewTool("unfollowUser",
-		mcp.WithDescription("Unfollow a Bluesky user"),
-		mcp.WithString("did",
+	removeFromListTool := mcp.NewTool("removeFromList",
+		mcp.WithDescription("Remove a user from one of your lists."),
+		mcp.WithString("listUri",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf(uriDescription, "list to remove the user from")),
+		),
+		mcp.WithString("actor",
 			mcp.Required(),
-			mcp.Description("DID of the user to unfollow. Must be a valid Bluesky DID (e.g., did:plc:... or did:web:...)."),
+			mcp.Description(fmt.Sprintf(actorDescription, "user to remove")),
 		),
 	)
 
-	s.AddTool(unfollowUserTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		did, err := request.RequireString("did")
+	s.AddTool(removeFromListTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		listUri, err := getURIParam(ctx, c, request, "listUri", "app.bsky.graph.list", true)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
-
-		user, err := appbsky.ActorGetProfile(ctx, c, did)
+		did, err := getActorParam(ctx, c, request)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Error getting user profile: %s", err)), nil
+			return mcp.NewToolResultError(err.Error()), nil
 		}
-		if user.Viewer.Following == nil {
-			return mcp.NewToolResultError(fmt.Sprintf("You are not following user: %s", did)), nil
+		_, list, _, err := getOwnList(ctx, c, listUri)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
 		}
-		follow := user.Viewer.Following
 
-		parsed, err := parseURI(*follow)
+		itemUri, err := findListItem(ctx, c, listUri, did.String())
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Error parsing follow URI: %s", err)), nil
+			return mcp.NewToolResultError(err.Error()), nil
 		}
-		r, err := comatproto.RepoDeleteRecord(ctx, c, &comatproto.RepoDeleteRecord_Input{
-			Collection: parsed.collection,
-			Repo:       parsed.repo,
-			Rkey:       parsed.rkey,
-		})
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Error deleting follow: %s", err)), nil
+		if itemUri == "" {
+			return mcp.NewToolResultError(fmt.Sprintf("User %s is not on list %q", did, list.Name)), nil
 		}
-		return mcp.NewToolResultText(fmt.Sprintf("Successfully unfollowed user. Commit CID: %s", r.Commit.Cid)), nil
-	})
-
-	notificationTool := mcp.NewTool("readNotifications",
-		mcp.WithDescription("Reads notifications"),
-		mcp.WithString("cursor",
-			mcp.Description("Optional cursor to paginate through notifications. If not provided, will read the latest notifications."),
-		),
-		mcp.WithNumber("limit",
-			mcp.Description("Optional limit on the number of notifications to read. Default is 50."),
-		),
-	)
 
-	s.AddTool(notificationTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		cursor := request.GetString("cursor", "")
-		limit := request.GetInt("limit", 50)
-		r, err := appbsky.NotificationListNotifications(ctx, c, cursor, int64(limit), false, nil, "")
+		parsed, err := parseURI(itemUri)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Error reading notifications: %s", err)), nil
+			return mcp.NewToolResultError(fmt.Sprintf("Error parsing list item URI: %s", err)), nil
 		}
-
-		str := fmt.Sprintf("%d notifications (cursor: %s):\n", len(r.Notifications), *r.Cursor)
-
-		for _, n := range r.Notifications {
-			if n.Reason == "like" {
-				like := n.Record.Val.(*appbsky.FeedLike)
-				uri, err := parseURI(like.Subject.Uri)
-				if err != nil {
-					fmt.Println("Error parsing URI:", err)
-					continue
-				}
-				likesubject, err := comatproto.RepoGetRecord(ctx, c, like.Subject.Cid, uri.collection, uri.repo, uri.rkey)
-				if err != nil {
-					fmt.Println("Error getting like subject:", err)
-					continue
-				}
-				str += fmt.Sprintf("%s (%s) liked your post (URI %s): %s\n", *n.Author.DisplayName, n.Author.Did, like.Subject.Uri, likesubject.Value.Val.(*appbsky.FeedPost).Text)
-			}
-			if n.Reason == "mention" {
-				str += fmt.Sprintf("%s (%s) mentioned you (URI %s): %s\n", *n.Author.DisplayName, n.Author.Did, n.Uri, n.Record.Val.(*appbsky.FeedPost).Text)
-			}
-			if n.Reason == "follow" {
-				str += fmt.Sprintf("%s (%s) followed you\n", *n.Author.DisplayName, n.Author.Did)
-			}
-			if n.Reason == "reply" {
-				reply := n.Record.Val.(*appbsky.FeedPost)
-				uri, err := parseURI(reply.Reply.Parent.Uri)
-				if err != nil {
-					fmt.Println("Error parsing URI:", err)
-					continue
-				}
-				replysubject, err := comatproto.RepoGetRecord(ctx, c, reply.Reply.Parent.Cid, uri.collection, uri.repo, uri.rkey)
-				if err != nil {
-					fmt.Println("Error getting reply subject:", err)
-					continue
-				}
-				str += fmt.Sprintf("%s (%s) replied to your post (URI %s, contents %s) with: %s\n", *n.Author.DisplayName, n.Author.Did, reply.Reply.Parent.Uri, replysubject.Value.Val.(*appbsky.FeedPost).Text, reply.Text)
-			}
+		if _, err := comatproto.RepoDeleteRecord(ctx, c, &comatproto.RepoDeleteRecord_Input{
+			Collection: parsed.collection,
+			Repo:       parsed.repo,
+			Rkey:       parsed.rkey,
+		}); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error removing user from list: %s", err)), nil
 		}
-		fmt.Println(str)
-		return mcp.NewToolResultText(str), nil
+		return mcp.NewToolResultText(fmt.Sprintf("Successfully removed %s from list %q", did, list.Name)), nil
 	})
 
-	readFeedTool := mcp.NewTool("readFeed",
-		mcp.WithDescription("Reads a feed."),
-		mcp.WithString("feedUri",
-			mcp.Description("Optional feed URI to read. If a feed URI is not provided, it will read the home feed (Discover)."),
+	getListMembersTool := mcp.NewTool("getListMembers",
+		mcp.WithDescription("Gets the details and members of a list."),
+		mcp.WithString("listUri",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf(uriDescription, "list")),
 		),
 		mcp.WithString("cursor",
-			mcp.Description("Optional cursor to paginate through posts. If not provided, will read the latest posts."),
+			mcp.Description("Optional cursor to paginate through members. If not provided, will read the first members."),
 		),
 		mcp.WithNumber("limit",
-			mcp.Description("Optional limit on the number of posts to read. Default is 50."),
+			mcp.Description("Optional limit on the number of members to read. Possible values: >= 1 and <= 100. Default is 50."),
 		),
+		withOutputFormat(),
+		mcp.WithOutputSchema[listMembersOutput](),
 	)
 
-	s.AddTool(readFeedTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		feedUri, err := request.RequireString("feedUri")
+	s.AddTool(getListMembersTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		listUri, err := getURIParam(ctx, c, request, "listUri", "app.bsky.graph.list", true)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
 		cursorParam := request.GetString("cursor", "")
 		limit := request.GetInt("limit", 50)
-		var posts []*appbsky.FeedDefs_FeedViewPost
-		var cursor string = ""
 
-		savedFeeds, err := getSavedFeeds(ctx, c)
+		r, err := appbsky.GraphGetList(ctx, c, cursorParam, int64(limit), listUri)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Error getting saved feeds: %s", err)), nil
+			return mcp.NewToolResultError(fmt.Sprintf("Error getting list: %s", err)), nil
 		}
 
-		if feedUri == "" {
-			// there's a builtin getTimeline
-			r, err := appbsky.FeedGetTimeline(ctx, c, "", cursorParam, int64(limit))
-			if err != nil {
-				return mcp.NewToolResultError(fmt.Sprintf("Error reading home feed: %s", err)), nil
-			}
-			posts = r.Feed
-			if r.Cursor != nil {
-				cursor = *r.Cursor
-			}
-		} else {
-			// read a specific feed
-			r, err := appbsky.FeedGetFeed(ctx, c, cursorParam, feedUri, int64(limit))
-			if err != nil {
-				return mcp.NewToolResultError(fmt.Sprintf("Error reading home feed: %s", err)), nil
-			}
-			posts = r.Feed
-			if r.Cursor != nil {
-				cursor = *r.Cursor
+		str := fmt.Sprintf("List %s\n%d members (cursor: %s):\n", render.List(r.List), len(r.Items), render.CursorOrEmpty(r.Cursor))
+		for _, item := range r.Items {
+			if item != nil {
+				str += fmt.Sprintf("%s (list item URI %s)\n", render.ProfileView(item.Subject), item.Uri)
 			}
 		}
 
-		str := fmt.Sprintf("\"%s\" Feed (cursor: %s):\n", savedFeeds.Items[0].Value, cursor)
-
-		str += generateStringFromPosts(posts)
-
-		return mcp.NewToolResultText(str), nil
+		return formatResult(request, listMembersFromAPI(r), str), nil
 	})
 
-	readListFeedTool := mcp.NewTool("readListFeed",
-		mcp.WithDescription("Reads a feed."),
-		mcp.WithString("listUri",
-			mcp.Required(),
-			mcp.Description("URI of list to get feed from."),
+	getListsTool := mcp.NewTool("getLists",
+		mcp.WithDescription("Gets the lists created by a user."),
+		mcp.WithString("actor",
+			mcp.Description(fmt.Sprintf(actorDescription, "user to get the lists of")+" If not provided, your own lists are returned."),
 		),
 		mcp.WithString("cursor",
-			mcp.Description("Optional cursor to paginate through posts. If not provided, will read the latest posts."),
+			mcp.Description("Optional cursor to paginate through lists. If not provided, will read the first lists."),
 		),
 		mcp.WithNumber("limit",
-			mcp.Description("Optional limit on the number of posts to read. Default is 50."),
+			mcp.Description("Optional limit on the number of lists to read. Possible values: >= 1 and <= 100. Default is 50."),
 		),
+		withOutputFormat(),
+		mcp.WithOutputSchema[listsOutput](),
 	)
 
-	s.AddTool(readListFeedTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		listUri, err := request.RequireString("listUri")
-		if err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
+	s.AddTool(getListsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		actor := c.Auth.Did
+		if request.GetString("actor", "") != "" {
+			did, err := getActorParam(ctx, c, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			actor = did.String()
 		}
 		cursorParam := request.GetString("cursor", "")
 		limit := request.GetInt("limit", 50)
-		var posts []*appbsky.FeedDefs_FeedViewPost
-		var cursor string = ""
 
-		l, err := appbsky.GraphGetList(ctx, c, cursorParam, int64(limit), listUri)
+		r, err := appbsky.GraphGetLists(ctx, c, actor, cursorParam, int64(limit))
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Error getting list: %s", err)), nil
+			return mcp.NewToolResultError(fmt.Sprintf("Error getting lists: %s", err)), nil
 		}
-		listName := l.List.Name
 
-		r, err := appbsky.FeedGetListFeed(ctx, c, cursorParam, int64(limit), listUri)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Error reading list feed: %s", err)), nil
+		str := fmt.Sprintf("%d lists (cursor: %s):\n", len(r.Lists), render.CursorOrEmpty(r.Cursor))
+		for _, l := range r.Lists {
+			str += render.List(l) + "\n"
 		}
-		if r.Cursor != nil {
-			cursor = *r.Cursor
-		}
-		posts = r.Feed
 
-		str := fmt.Sprintf("Feed generated from list \"%s\" (cursor: %s):\n", listName, cursor)
-		str += generateStringFromPosts(posts)
-
-		return mcp.NewToolResultText(str), nil
+		return formatResult(request, listsFromViews(render.CursorOrEmpty(r.Cursor), r.Lists), str), nil
 	})
 
 	readAuthorFeedTool := mcp.NewTool("readAuthorFeed",
 		mcp.WithDescription("Reads a feed."),
 		mcp.WithString("actor",
 			mcp.Required(),
-			mcp.Description("AT-identifier of the author to read the feed from. Must be a valid Bluesky DID (e.g., did:plc:... or did:web:...)."),
+			mcp.Description(fmt.Sprintf(actorDescription, "author to read the feed from")),
 		),
 		mcp.WithString("cursor",
 			mcp.Description("Optional cursor to paginate through posts. If not provided, will read the latest posts."),
@@ -471,13 +1714,17 @@ func main() {
 		mcp.WithBoolean("includePins",
 			mcp.Description("Whether or not to include pinned posts."),
 		),
+		withIncludeImages(),
+		withOutputFormat(),
+		mcp.WithOutputSchema[feedOutput](),
 	)
 
 	s.AddTool(readAuthorFeedTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		actor, err := request.RequireString("actor")
+		did, err := getActorParam(ctx, c, request)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
+		actor := did.String()
 		cursorParam := request.GetString("cursor", "")
 		limit := request.GetInt("limit", 50)
 		filter := request.GetString("filter", "posts_with_replies")
@@ -489,7 +1736,7 @@ func main() {
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error getting list: %s", err)), nil
 		}
-		userName := *a.DisplayName
+		userName := render.Actor(a.DisplayName, a.Handle, a.Did)
 
 		r, err := appbsky.FeedGetAuthorFeed(ctx, c, actor, cursorParam, filter, includePins, int64(limit))
 		if err != nil {
@@ -500,10 +1747,11 @@ func main() {
 		}
 		posts = r.Feed
 
-		str := fmt.Sprintf("Feed generated from posts by actor \"%s\" (cursor: %s):\n", userName, cursor)
-		str += generateStringFromPosts(posts)
+		str := fmt.Sprintf("Feed generated from posts by %s (cursor: %s):\n", userName, cursor)
+		str += render.Posts(posts)
 
-		return mcp.NewToolResultText(str), nil
+		out := feedFromFeedViews(userName, cursor, posts)
+		return withImages(ctx, request, formatResult(request, out, str), out.Posts), nil
 	})
 
 	readLikedPostsTool := mcp.NewTool("readLikedPosts",
@@ -514,6 +1762,9 @@ func main() {
 		mcp.WithNumber("limit",
 			mcp.Description("Optional limit on the number of posts to read. Default is 50."),
 		),
+		withIncludeImages(),
+		withOutputFormat(),
+		mcp.WithOutputSchema[feedOutput](),
 	)
 
 	s.AddTool(readLikedPostsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -523,28 +1774,32 @@ func main() {
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error reading liked posts: %s", err)), nil
 		}
+		out := feedFromFeedViews("Liked posts", render.CursorOrEmpty(r.Cursor), r.Feed)
 		if len(r.Feed) == 0 {
-			return mcp.NewToolResultText("No liked posts found."), nil
+			return formatResult(request, out, "No liked posts found."), nil
 		}
-		str := fmt.Sprintf("Liked posts (cursor: %s):\n", *r.Cursor)
-		str += generateStringFromPosts(r.Feed)
+		str := fmt.Sprintf("Liked posts (cursor: %s):\n", render.CursorOrEmpty(r.Cursor))
+		str += render.Posts(r.Feed)
 
-		return mcp.NewToolResultText(str), nil
+		return withImages(ctx, request, formatResult(request, out, str), out.Posts), nil
 	})
 
 	readProfileTool := mcp.NewTool("readProfile",
 		mcp.WithDescription("Reads a Bluesky profile."),
 		mcp.WithString("actor",
 			mcp.Required(),
-			mcp.Description("AT-identifier of the actor to read the profile from. Must be a valid Bluesky DID (e.g., did:plc:... or did:web:...)."),
+			mcp.Description(fmt.Sprintf(actorDescription, "actor to read the profile of")),
 		),
+		withOutputFormat(),
+		mcp.WithOutputSchema[profileOutput](),
 	)
 
 	s.AddTool(readProfileTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		actor, err := request.RequireString("actor")
+		did, err := getActorParam(ctx, c, request)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
+		actor := did.String()
 
 		profile, err := appbsky.ActorGetProfile(ctx, c, actor)
 		if err != nil {
@@ -554,30 +1809,26 @@ func main() {
 		pronounsdiyDID := "did:plc:wkoofae5uytcm7bjncmev6n6"
 		labels, err := comatproto.LabelQueryLabels(ctx, c, "", 100, []string{pronounsdiyDID}, []string{actor})
 
-		verified := "No"
-		if profile.Verification.TrustedVerifierStatus == "valid" || profile.Verification.VerifiedStatus == "valid" {
-			verified = "Yes"
-		}
-
-		str := fmt.Sprintf("Profile of %s (%s):\n", *profile.DisplayName, profile.Did)
-		str += fmt.Sprintf("Handle: %s\n", profile.Handle)
-		str += fmt.Sprintf("Verified: %s", verified)
-		str += fmt.Sprintf("Bio: %s\n", *profile.Description)
-		str += fmt.Sprintf("Followers: %d\n", *profile.FollowersCount)
-		str += fmt.Sprintf("Following: %d\n", *profile.FollowsCount)
-		str += fmt.Sprintf("Posts: %d\n", *profile.PostsCount)
-
-		for _, label := range labels.Labels {
-			if label.Src == pronounsdiyDID {
-				str += fmt.Sprintf("Pronouns: %s\n", label.Val)
+		var pronouns []string
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error getting pronoun labels:", err)
+		} else {
+			for _, label := range labels.Labels {
+				if label != nil && label.Src == pronounsdiyDID {
+					pronouns = append(pronouns, label.Val)
+				}
 			}
 		}
 
-		return mcp.NewToolResultText(str), nil
+		str := render.Profile(profile, pronouns)
+
+		return formatResult(request, profileFromDetailed(profile, pronouns), str), nil
 	})
 
 	listSavedFeedsTool := mcp.NewTool("listSavedFeeds",
 		mcp.WithDescription("Lists saved feeds."),
+		withOutputFormat(),
+		mcp.WithOutputSchema[savedFeedsOutput](),
 	)
 
 	s.AddTool(listSavedFeedsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -587,27 +1838,25 @@ func main() {
 		}
 
 		str := "Saved Feeds:\n"
+		out := savedFeedsOutput{Feeds: []feedGeneratorOutput{}}
 		for _, item := range savedFeeds.Items {
 			feedGen, err := appbsky.FeedGetFeedGenerator(ctx, c, item.Value)
 			if err != nil {
 				fmt.Printf("Error getting feed generator: %s\n", err.Error())
 				continue
 			}
-			isOnline := "Currently online"
-			if !feedGen.IsOnline {
-				isOnline = "Currently offline"
-			}
-			str += fmt.Sprintf("%s URI: %s, (%s, %d likes) — %s", feedGen.View.DisplayName, feedGen.View.Uri, isOnline, *feedGen.View.LikeCount, *feedGen.View.Description)
+			str += render.FeedGenerator(feedGen)
+			out.Feeds = append(out.Feeds, feedGeneratorFromAPI(feedGen))
 		}
 
-		return mcp.NewToolResultText(str), nil
+		return formatResult(request, out, str), nil
 	})
 
 	getFollowersTool := mcp.NewTool("getFollowers",
 		mcp.WithDescription("Gets followers of a Bluesky actor."),
 		mcp.WithString("actor",
 			mcp.Required(),
-			mcp.Description("AT-identifier of the actor to get followers from. Must be a valid Bluesky DID (e.g., did:plc:... or did:web:...)."),
+			mcp.Description(fmt.Sprintf(actorDescription, "actor to get the followers of")),
 		),
 		mcp.WithString("cursor",
 			mcp.Description("Optional cursor to paginate through posts. If not provided, will read the latest posts."),
@@ -615,13 +1864,16 @@ func main() {
 		mcp.WithNumber("limit",
 			mcp.Description("Optional limit on the number of posts to read. Default is 50."),
 		),
+		withOutputFormat(),
+		mcp.WithOutputSchema[actorsOutput](),
 	)
 
 	s.AddTool(getFollowersTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		actor, err := request.RequireString("actor")
+		did, err := getActorParam(ctx, c, request)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
+		actor := did.String()
 		cursorParam := request.GetString("cursor", "")
 		limit := request.GetInt("limit", 50)
 
@@ -630,19 +1882,21 @@ func main() {
 			return mcp.NewToolResultError(fmt.Sprintf("Error getting followers: %s", err)), nil
 		}
 
-		str := fmt.Sprintf("Followers of %s (%s):\n", *followers.Subject.DisplayName, followers.Subject.Did)
+		str := fmt.Sprintf("Followers of %s (cursor: %s):\n", render.ProfileView(followers.Subject), render.CursorOrEmpty(followers.Cursor))
 		for _, follower := range followers.Followers {
-			str += fmt.Sprintf("%s (%s) — %s\n", *follower.DisplayName, follower.Did, *follower.Description)
+			str += render.ProfileView(follower) + "\n"
 		}
 
-		return mcp.NewToolResultText(str), nil
+		subject := actorFromView(followers.Subject)
+		out := actorsOutput{Subject: &subject, Cursor: render.CursorOrEmpty(followers.Cursor), Actors: actorsFromViews(followers.Followers)}
+		return formatResult(request, out, str), nil
 	})
 
 	getFollowingTool := mcp.NewTool("getFollowing",
 		mcp.WithDescription("Gets those who a Bluesky actor follows."),
 		mcp.WithString("actor",
 			mcp.Required(),
-			mcp.Description("AT-identifier of the actor to get followers from. Must be a valid Bluesky DID (e.g., did:plc:... or did:web:...)."),
+			mcp.Description(fmt.Sprintf(actorDescription, "actor to get the follows of")),
 		),
 		mcp.WithString("cursor",
 			mcp.Description("Optional cursor to paginate through posts. If not provided, will read the latest posts."),
@@ -650,13 +1904,16 @@ func main() {
 		mcp.WithNumber("limit",
 			mcp.Description("Optional limit on the number of posts to read. Default is 50."),
 		),
+		withOutputFormat(),
+		mcp.WithOutputSchema[actorsOutput](),
 	)
 
 	s.AddTool(getFollowingTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		actor, err := request.RequireString("actor")
+		did, err := getActorParam(ctx, c, request)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
+		actor := did.String()
 		cursorParam := request.GetString("cursor", "")
 		limit := request.GetInt("limit", 50)
 
@@ -665,12 +1922,14 @@ func main() {
 			return mcp.NewToolResultError(fmt.Sprintf("Error getting followers: %s", err)), nil
 		}
 
-		str := fmt.Sprintf("Followers of %s (%s):\n", *following.Subject.DisplayName, following.Subject.Did)
+		str := fmt.Sprintf("Users followed by %s (cursor: %s):\n", render.ProfileView(following.Subject), render.CursorOrEmpty(following.Cursor))
 		for _, follow := range following.Follows {
-			str += fmt.Sprintf("%s (%s) — %s\n", *follow.DisplayName, follow.Did, *follow.Description)
+			str += render.ProfileView(follow) + "\n"
 		}
 
-		return mcp.NewToolResultText(str), nil
+		subject := actorFromView(following.Subject)
+		out := actorsOutput{Subject: &subject, Cursor: render.CursorOrEmpty(following.Cursor), Actors: actorsFromViews(following.Follows)}
+		return formatResult(request, out, str), nil
 	})
 
 	getTrendingTool := mcp.NewTool("getTrending",
@@ -679,6 +1938,8 @@ func main() {
 			mcp.Description("Maximum number of categories to get"),
 			mcp.DefaultNumber(5),
 		),
+		withOutputFormat(),
+		mcp.WithOutputSchema[trendsOutput](),
 	)
 
 	s.AddTool(getTrendingTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -687,8 +1948,9 @@ func main() {
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error getting trends: %s", err)), nil
 		}
+		out := trendsOutput{Trends: []trendOutput{}}
 		if len(r.Trends) == 0 {
-			return mcp.NewToolResultText("No trends found."), nil
+			return formatResult(request, out, "No trends found."), nil
 		}
 		str := fmt.Sprintf("Top %d trending categories:\n", len(r.Trends))
 		for index, trend := range r.Trends {
@@ -697,13 +1959,22 @@ func main() {
 			str += fmt.Sprintf("Rank: %d, Name: %s, Category: %s, Posts: %d, Time Started: %s, Status: %s, URI: %s\n",
 				index+1,
 				trend.DisplayName,
-				*trend.Category,
+				render.StringOrEmpty(trend.Category),
 				trend.PostCount,
 				trend.StartedAt,
-				*trend.Status,
+				render.StringOrEmpty(trend.Status),
 				processedUri)
-		}
-		return mcp.NewToolResultText(str), nil
+			out.Trends = append(out.Trends, trendOutput{
+				Rank:        index + 1,
+				DisplayName: trend.DisplayName,
+				Category:    render.StringOrEmpty(trend.Category),
+				PostCount:   trend.PostCount,
+				StartedAt:   trend.StartedAt,
+				Status:      render.StringOrEmpty(trend.Status),
+				FeedUri:     processedUri,
+			})
+		}
+		return formatResult(request, out, str), nil
 	})
 
 	searchPostsTool := mcp.NewTool("searchPosts",
@@ -751,6 +2022,9 @@ func main() {
 		mcp.WithString("cursor",
 			mcp.Description("Optional pagination mechanism; may not necessarily allow scrolling through entire result set."),
 		),
+		withIncludeImages(),
+		withOutputFormat(),
+		mcp.WithOutputSchema[feedOutput](),
 	)
 
 	s.AddTool(searchPostsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -775,10 +2049,11 @@ func main() {
 			return mcp.NewToolResultError(fmt.Sprintf("Error searching posts: %s", err)), nil
 		}
 
-		resultStr := fmt.Sprintf("Search Results (cursor: %s):\n", *r.Cursor)
-		resultStr += generateStringFromPostViews(&r.Posts)
+		resultStr := fmt.Sprintf("Search Results (cursor: %s):\n", render.CursorOrEmpty(r.Cursor))
+		resultStr += render.PostViews(&r.Posts)
 
-		return mcp.NewToolResultText(resultStr), nil
+		out := feedFromPostViews(render.CursorOrEmpty(r.Cursor), r.Posts)
+		return withImages(ctx, request, formatResult(request, out, resultStr), out.Posts), nil
 	})
 
 	searchUsersTool := mcp.NewTool("searchUsers",
@@ -794,6 +2069,8 @@ func main() {
 			mcp.Description("Maximum number of results to return. Possible values: >= 1 and <= 100. Default is 25."),
 			mcp.DefaultNumber(25),
 		),
+		withOutputFormat(),
+		mcp.WithOutputSchema[actorsOutput](),
 	)
 
 	s.AddTool(searchUsersTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -809,12 +2086,13 @@ func main() {
 			return mcp.NewToolResultError(fmt.Sprintf("Error searching actors: %s", err)), nil
 		}
 
-		resultStr := fmt.Sprintf("Search Results (cursor: %s):\n", *r.Cursor)
+		resultStr := fmt.Sprintf("Search Results (cursor: %s):\n", render.CursorOrEmpty(r.Cursor))
 		for _, actor := range r.Actors {
-			resultStr += fmt.Sprintf("%s (%s) - %s\n", *actor.DisplayName, actor.Did, actor.Handle)
+			resultStr += render.ProfileView(actor) + "\n"
 		}
 
-		return mcp.NewToolResultText(resultStr), nil
+		out := actorsOutput{Cursor: render.CursorOrEmpty(r.Cursor), Actors: actorsFromViews(r.Actors)}
+		return formatResult(request, out, resultStr), nil
 	})
 
 	fmt.Println("Starting server...")
@@ -852,14 +2130,17 @@ func createRecord(ctx context.Context, c *xrpc.Client, rec *comatproto.RepoCreat
 	return res, nil
 }
 
-func makePost(ctx context.Context, c *xrpc.Client, m string) *comatproto.RepoCreateRecord_Input {
+func makePost(ctx context.Context, c *xrpc.Client, m string, reply *appbsky.FeedPost_ReplyRef, embed *appbsky.FeedPost_Embed) *comatproto.RepoCreateRecord_Input {
+	text, facets := getFacetsFromString(ctx, c, m)
 	p := &comatproto.RepoCreateRecord_Input{
 		Collection: "app.bsky.feed.post",
 		Record: &lexutil.LexiconTypeDecoder{
 			Val: &appbsky.FeedPost{
 				CreatedAt: syntax.DatetimeNow().String(),
-				Text:      m,
-				Facets:    getFacetsFromString(ctx, c, m),
+				Text:      text,
+				Facets:    facets,
+				Reply:     reply,
+				Embed:     embed,
 			},
 		},
 		Repo: c.Auth.Did,
@@ -868,120 +2149,239 @@ func makePost(ctx context.Context, c *xrpc.Client, m string) *comatproto.RepoCre
 	return p
 }
 
-func makeRepost(c *xrpc.Client, subj string) (*comatproto.RepoCreateRecord_Input, error) {
-	uri, err := parseURI(subj)
+// makeReplyRef resolves the post at parentUri and builds the reply reference for
+// a post replying to it. The thread root is taken from the parent's own reply
+// reference when the parent is itself a reply.
+func makeReplyRef(ctx context.Context, c *xrpc.Client, parentUri string) (*appbsky.FeedPost_ReplyRef, error) {
+	uri, err := parseURI(parentUri)
 	if err != nil {
-		fmt.Println("Error parsing URI:", err)
 		return nil, err
 	}
+	if uri.collection != "app.bsky.feed.post" {
+		return nil, fmt.Errorf("reply subject must be a post, got collection %s", uri.collection)
+	}
 
-	r := &comatproto.RepoCreateRecord_Input{
-		Collection: "app.bsky.feed.repost",
-		Record: &lexutil.LexiconTypeDecoder{
-			Val: &appbsky.FeedRepost{
-				CreatedAt: syntax.DatetimeNow().String(),
-				Subject: &comatproto.RepoStrongRef{
-					Cid: uri.rkey,
-					Uri: subj,
-				},
-			},
-		},
-		Repo: c.Auth.Did,
+	parent, err := getPostView(ctx, c, parentUri)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCanReply(parent); err != nil {
+		return nil, err
 	}
 
-	return r, nil
-}
+	var fp *appbsky.FeedPost
+	if parent.Record != nil {
+		fp, _ = parent.Record.Val.(*appbsky.FeedPost)
+	}
+	if fp == nil {
+		return nil, fmt.Errorf("reply subject is not a post: %s", parentUri)
+	}
+
+	parentRef := &comatproto.RepoStrongRef{
+		Cid: parent.Cid,
+		Uri: parent.Uri,
+	}
+	rootRef := parentRef
+	if fp.Reply != nil && fp.Reply.Root != nil {
+		rootRef = &comatproto.RepoStrongRef{
+			Cid: fp.Reply.Root.Cid,
+			Uri: fp.Reply.Root.Uri,
+		}
 
-func getFacetsFromString(ctx context.Context, c *xrpc.Client, s string) []*appbsky.RichtextFacet {
-	fmt.Println("processing string for facets...")
-	lreg, _ := regexp.Compile(`https:\/\/(?:www\.)?[-a-zA-Z0-9@:%._\+~#=]{1,256}\.[a-zA-Z0-9]{2,6}\b(?:[-a-zA-Z0-9@:%_\+.~#?&//=]*)`)
-	mreg, _ := regexp.Compile(`@([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)+[a-zA-Z]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?`)
-	treg, _ := regexp.Compile(`#(\S*)`)
+		// the threadgate lives on the root, so check it too if it's still around
+		posts, err := appbsky.FeedGetPosts(ctx, c, []string{rootRef.Uri})
+		if err != nil {
+			return nil, fmt.Errorf("error getting thread root: %w", err)
+		}
+		if len(posts.Posts) > 0 {
+			if err := checkCanReply(posts.Posts[0]); err != nil {
+				return nil, fmt.Errorf("thread root: %w", err)
+			}
+		}
+	}
 
-	var facets []*appbsky.RichtextFacet
+	return &appbsky.FeedPost_ReplyRef{
+		Parent: parentRef,
+		Root:   rootRef,
+	}, nil
+}
 
-	// links should not be preceded by an @ or #
-	for _, indices := range lreg.FindAllStringIndex(s, -1) {
-		startIndex := indices[0]
-		endIndex := indices[1]
-		match := s[startIndex:endIndex]
+// makeQuoteRef resolves the post at quoteUri into a strong ref suitable for
+// embedding, refusing if the author's postgate doesn't allow it.
+func makeQuoteRef(ctx context.Context, c *xrpc.Client, quoteUri string) (*comatproto.RepoStrongRef, error) {
+	uri, err := parseURI(quoteUri)
+	if err != nil {
+		return nil, err
+	}
+	if uri.collection != "app.bsky.feed.post" {
+		return nil, fmt.Errorf("quote subject must be a post, got collection %s", uri.collection)
+	}
 
-		fmt.Println(match)
+	quoted, err := getPostView(ctx, c, quoteUri)
+	if err != nil {
+		return nil, err
+	}
+	if quoted.Viewer != nil && quoted.Viewer.EmbeddingDisabled != nil && *quoted.Viewer.EmbeddingDisabled {
+		return nil, fmt.Errorf("the author of %s has disabled quote posts", quoted.Uri)
+	}
 
-		if startIndex == 0 {
-			// cannot be preceded by an @ or #
-			facet := &appbsky.RichtextFacet{
-				Features: []*appbsky.RichtextFacet_Features_Elem{
-					&appbsky.RichtextFacet_Features_Elem{RichtextFacet_Link: &appbsky.RichtextFacet_Link{Uri: match}},
-				},
-				Index: &appbsky.RichtextFacet_ByteSlice{
-					ByteEnd:   int64(endIndex),
-					ByteStart: int64(startIndex),
-				},
+	// the postgate shares its rkey with the post it applies to
+	author := uri.repo
+	if quoted.Author != nil {
+		author = quoted.Author.Did
+	}
+	if author != c.Auth.Did {
+		gate, err := comatproto.RepoGetRecord(ctx, c, "", "app.bsky.feed.postgate", author, uri.rkey)
+		if err == nil && gate.Value != nil {
+			if pg, ok := gate.Value.Val.(*appbsky.FeedPostgate); ok {
+				for _, rule := range pg.EmbeddingRules {
+					if rule != nil && rule.FeedPostgate_DisableRule != nil {
+						return nil, fmt.Errorf("the author of %s has disabled quote posts", quoted.Uri)
+					}
+				}
 			}
-			facets = append(facets, facet)
 		}
+	}
 
-		preChar := s[startIndex-1 : startIndex]
-		if preChar != "@" && preChar != "#" {
-			facet := &appbsky.RichtextFacet{
-				Features: []*appbsky.RichtextFacet_Features_Elem{
-					&appbsky.RichtextFacet_Features_Elem{RichtextFacet_Link: &appbsky.RichtextFacet_Link{Uri: match}},
-				},
-				Index: &appbsky.RichtextFacet_ByteSlice{
-					ByteEnd:   int64(endIndex),
-					ByteStart: int64(startIndex),
-				},
-			}
-			facets = append(facets, facet)
+	return &comatproto.RepoStrongRef{
+		Cid: quoted.Cid,
+		Uri: quoted.Uri,
+	}, nil
+}
+
+// makeEmbed combines an optional quoted post and optional media into a post embed.
+// Returns nil if there is nothing to embed.
+func makeEmbed(quote *comatproto.RepoStrongRef, media *appbsky.EmbedRecordWithMedia_Media) *appbsky.FeedPost_Embed {
+	switch {
+	case quote != nil && media != nil:
+		return &appbsky.FeedPost_Embed{
+			EmbedRecordWithMedia: &appbsky.EmbedRecordWithMedia{
+				Media:  media,
+				Record: &appbsky.EmbedRecord{Record: quote},
+			},
+		}
+	case quote != nil:
+		return &appbsky.FeedPost_Embed{
+			EmbedRecord: &appbsky.EmbedRecord{Record: quote},
+		}
+	case media != nil:
+		return &appbsky.FeedPost_Embed{
+			EmbedImages:   media.EmbedImages,
+			EmbedVideo:    media.EmbedVideo,
+			EmbedExternal: media.EmbedExternal,
 		}
 	}
+	return nil
+}
 
-	for _, indices := range mreg.FindAllStringIndex(s, -1) {
-		startIndex := indices[0]
-		endIndex := indices[1]
-		match := s[startIndex:endIndex]
+// getPostView fetches a single post view, distinguishing between posts that
+// don't exist and posts that exist but are hidden from the logged in user.
+func getPostView(ctx context.Context, c *xrpc.Client, uri string) (*appbsky.FeedDefs_PostView, error) {
+	posts, err := appbsky.FeedGetPosts(ctx, c, []string{uri})
+	if err != nil {
+		return nil, fmt.Errorf("error getting post: %w", err)
+	}
+	if len(posts.Posts) > 0 {
+		return posts.Posts[0], nil
+	}
 
-		fmt.Println(match)
+	parsed, err := parseURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := comatproto.RepoGetRecord(ctx, c, "", parsed.collection, parsed.repo, parsed.rkey); err != nil {
+		return nil, fmt.Errorf("post not found, it may have been deleted: %s", uri)
+	}
+	return nil, fmt.Errorf("post is not visible, the author may have blocked you or been taken down: %s", uri)
+}
 
-		r, err := comatproto.IdentityResolveHandle(ctx, c, match[1:]) // skip the @
-		if err != nil {
-			fmt.Println("Error resolving handle:", err)
-			continue
+// checkCanReply returns an error if the logged in user is not allowed to reply to p.
+func checkCanReply(p *appbsky.FeedDefs_PostView) error {
+	if p.Author != nil && p.Author.Viewer != nil {
+		if p.Author.Viewer.BlockedBy != nil && *p.Author.Viewer.BlockedBy {
+			return fmt.Errorf("cannot reply to %s: the author has blocked you", p.Uri)
 		}
-
-		facet := &appbsky.RichtextFacet{
-			Features: []*appbsky.RichtextFacet_Features_Elem{
-				&appbsky.RichtextFacet_Features_Elem{RichtextFacet_Mention: &appbsky.RichtextFacet_Mention{Did: r.Did}},
-			},
-			Index: &appbsky.RichtextFacet_ByteSlice{
-				ByteEnd:   int64(endIndex),
-				ByteStart: int64(startIndex),
-			},
+		if p.Author.Viewer.Blocking != nil || p.Author.Viewer.BlockingByList != nil {
+			return fmt.Errorf("cannot reply to %s: you are blocking the author", p.Uri)
 		}
-		facets = append(facets, facet)
 	}
+	if p.Viewer != nil && p.Viewer.ReplyDisabled != nil && *p.Viewer.ReplyDisabled {
+		return fmt.Errorf("cannot reply to %s: replies are restricted by the thread's threadgate", p.Uri)
+	}
+	return nil
+}
 
-	for _, indices := range treg.FindAllStringIndex(s, -1) {
-		startIndex := indices[0]
-		endIndex := indices[1]
-		match := s[startIndex:endIndex]
+func makeRepost(ctx context.Context, c *xrpc.Client, subj string) (*comatproto.RepoCreateRecord_Input, error) {
+	uri, err := parseURI(subj)
+	if err != nil {
+		return nil, err
+	}
+	if uri.collection != "app.bsky.feed.post" {
+		return nil, fmt.Errorf("repost subject must be a post, got collection %s", uri.collection)
+	}
 
-		fmt.Println(match)
+	post, err := getPostView(ctx, c, subj)
+	if err != nil {
+		return nil, err
+	}
+	if post.Viewer != nil && post.Viewer.Repost != nil {
+		return nil, fmt.Errorf("post already reposted (repost URI %s)", *post.Viewer.Repost)
+	}
 
-		facet := &appbsky.RichtextFacet{
-			Features: []*appbsky.RichtextFacet_Features_Elem{
-				&appbsky.RichtextFacet_Features_Elem{RichtextFacet_Tag: &appbsky.RichtextFacet_Tag{Tag: match[1:]}},
-			},
-			Index: &appbsky.RichtextFacet_ByteSlice{
-				ByteEnd:   int64(endIndex),
-				ByteStart: int64(startIndex),
+	r := &comatproto.RepoCreateRecord_Input{
+		Collection: "app.bsky.feed.repost",
+		Record: &lexutil.LexiconTypeDecoder{
+			Val: &appbsky.FeedRepost{
+				CreatedAt: syntax.DatetimeNow().String(),
+				Subject: &comatproto.RepoStrongRef{
+					Cid: post.Cid,
+					Uri: post.Uri,
+				},
 			},
-		}
-		facets = append(facets, facet)
+		},
+		Repo: c.Auth.Did,
+	}
+
+	return r, nil
+}
+
+// maxGetPosts is the most URIs app.bsky.feed.getPosts accepts per call.
+const maxGetPosts = 25
+
+// getPostsWorkers is how many getPosts calls may be in flight at once.
+const getPostsWorkers = 4
+
+// getPosts fetches post views for any number of URIs, splitting them into as
+// many getPosts calls as needed and making up to getPostsWorkers of them at a
+// time. Posts come back in request order; ones that can't be found are left
+// out.
+func getPosts(ctx context.Context, c *xrpc.Client, uris []string) ([]*appbsky.FeedDefs_PostView, error) {
+	chunks := slices.Collect(slices.Chunk(uris, maxGetPosts))
+	results := make([][]*appbsky.FeedDefs_PostView, len(chunks))
+	errs := make([]error, len(chunks))
+
+	sem := make(chan struct{}, getPostsWorkers)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			r, err := appbsky.FeedGetPosts(ctx, c, chunk)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			results[i] = r.Posts
+		}()
 	}
+	wg.Wait()
 
-	return facets
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return slices.Concat(results...), nil
 }
 
 func getSavedFeeds(ctx context.Context, c *xrpc.Client) (*appbsky.ActorDefs_SavedFeedsPrefV2, error) {
@@ -1004,89 +2404,3 @@ func getSavedFeeds(ctx context.Context, c *xrpc.Client) (*appbsky.ActorDefs_Save
 
 	return nil, fmt.Errorf("no saved feeds found") // hopefully never
 }
-
-func generateStringFromPosts(posts []*appbsky.FeedDefs_FeedViewPost) string {
-	str := ""
-	for _, post := range posts {
-		p := post.Post
-		fp := p.Record.Val.(*appbsky.FeedPost)
-		if post.Reason.FeedDefs_ReasonPin != nil {
-			str += fmt.Sprintf("Pinned post by %s (%s)",
-				*p.Author.DisplayName,
-				p.Author.Did)
-		} else if post.Reason.FeedDefs_ReasonRepost != nil {
-			reposter := post.Reason.FeedDefs_ReasonRepost.By
-			str += fmt.Sprintf("%s (%s) reposted a post by %s (%s)",
-				*reposter.DisplayName,
-				reposter.Did,
-				*p.Author.DisplayName,
-				p.Author.Did)
-		} else {
-			str += fmt.Sprintf("Post by %s (DID %s)",
-				*p.Author.DisplayName,
-				p.Author.Did)
-		}
-		str += fmt.Sprintf(" with %d likes, %d quotes, %d replies, a URI of %s, and a posting time of %s:\n",
-			*p.LikeCount,
-			*p.QuoteCount,
-			*p.ReplyCount,
-			p.Uri,
-			fp.CreatedAt)
-		str += fmt.Sprintf("Text: %s\n", fp.Text)
-		if fp.Facets != nil {
-			str += "Facets:\n"
-			facets := generateFacetListFromPost(fp)
-			for _, facet := range facets {
-				str += fmt.Sprintf("- %s\n", facet)
-			}
-		}
-	}
-	return str
-}
-
-func generateStringFromPostViews(postViews *[]*appbsky.FeedDefs_PostView) string {
-	str := ""
-	for _, postView := range *postViews {
-		p := postView
-		fp := postView.Record.Val.(*appbsky.FeedPost)
-		str += fmt.Sprintf("Post by %s (DID %s) with %d likes, %d quotes, %d replies, a URI of %s, and a posting time of %s:\n",
-			*p.Author.DisplayName,
-			p.Author.Did,
-			*p.LikeCount,
-			*p.QuoteCount,
-			*p.ReplyCount,
-			p.Uri,
-			fp.CreatedAt)
-		str += fmt.Sprintf("Text: %s\n", fp.Text)
-		if fp.Facets != nil {
-			str += "Facets:\n"
-			facets := generateFacetListFromPost(fp)
-			for _, facet := range facets {
-				str += fmt.Sprintf("- %s\n", facet)
-			}
-		}
-	}
-	return str
-}
-
-func generateFacetListFromPost(post *appbsky.FeedPost) []string {
-	var facets []string
-	if post.Facets != nil {
-		for _, facet := range post.Facets {
-			if facet.Features != nil {
-				for _, feature := range facet.Features {
-					if feature.RichtextFacet_Link != nil {
-						facets = append(facets, fmt.Sprintf("Link from byte %d to byte %d: %s", facet.Index.ByteStart, facet.Index.ByteEnd, feature.RichtextFacet_Link.Uri))
-					}
-					if feature.RichtextFacet_Mention != nil {
-						facets = append(facets, fmt.Sprintf("Mention from byte %d to byte %d: %s", facet.Index.ByteStart, facet.Index.ByteEnd, feature.RichtextFacet_Mention.Did))
-					}
-					if feature.RichtextFacet_Tag != nil {
-						facets = append(facets, fmt.Sprintf("Tag from byte %d to byte %d: %s", facet.Index.ByteStart, facet.Index.ByteEnd, feature.RichtextFacet_Tag.Tag))
-					}
-				}
-			}
-		}
-	}
-	return facets
-}
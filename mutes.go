@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	appbsky "github.com/bluesky-social/indigo/api/bsky"
+	xrpc "github.com/bluesky-social/indigo/xrpc"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+
+	"saturnvi/bsky-mcp/internal/render"
+)
+
+const mutedWordsPrefType = "app.bsky.actor.defs#mutedWordsPref"
+
+// rawPreferences are the logged in user's preferences as raw JSON. The typed
+// preferences union drops preference types it doesn't know about, which would
+// then be deleted when the preferences are written back, so anything that
+// modifies preferences reads and writes them this way instead.
+type rawPreferences struct {
+	Preferences []json.RawMessage `json:"preferences"`
+}
+
+// mutedWordIds generates the IDs of new muted words.
+var mutedWordIds = syntax.NewTIDClock(0)
+
+// preferencesMu serializes read-modify-writes of preferences made by this
+// server, so that concurrent tool calls don't undo each other's changes.
+var preferencesMu sync.Mutex
+
+// updatePreferences reads the user's preferences, passes them to update, and
+// writes back the result.
+func updatePreferences(ctx context.Context, c *xrpc.Client, update func(prefs *rawPreferences) error) error {
+	preferencesMu.Lock()
+	defer preferencesMu.Unlock()
+
+	var prefs rawPreferences
+	if err := c.Do(ctx, xrpc.Query, "", "app.bsky.actor.getPreferences", nil, nil, &prefs); err != nil {
+		return fmt.Errorf("error getting preferences: %w", err)
+	}
+	if err := update(&prefs); err != nil {
+		return err
+	}
+	if err := c.Do(ctx, xrpc.Procedure, "application/json", "app.bsky.actor.putPreferences", nil, &prefs, nil); err != nil {
+		return fmt.Errorf("error saving preferences: %w", err)
+	}
+	return nil
+}
+
+// mutedWords returns the muted words preference and its index, or an empty
+// preference and -1 if the user doesn't have one yet.
+func (p *rawPreferences) mutedWords() (*appbsky.ActorDefs_MutedWordsPref, int, error) {
+	for i, raw := range p.Preferences {
+		var typed struct {
+			Type string `json:"$type"`
+		}
+		if err := json.Unmarshal(raw, &typed); err != nil {
+			return nil, -1, fmt.Errorf("error reading preferences: %w", err)
+		}
+		if typed.Type != mutedWordsPrefType {
+			continue
+		}
+		var pref appbsky.ActorDefs_MutedWordsPref
+		if err := json.Unmarshal(raw, &pref); err != nil {
+			return nil, -1, fmt.Errorf("error reading muted words: %w", err)
+		}
+		return &pref, i, nil
+	}
+	return &appbsky.ActorDefs_MutedWordsPref{LexiconTypeID: mutedWordsPrefType}, -1, nil
+}
+
+// setMutedWords stores pref at index i, as returned by mutedWords.
+func (p *rawPreferences) setMutedWords(pref *appbsky.ActorDefs_MutedWordsPref, i int) error {
+	pref.LexiconTypeID = mutedWordsPrefType
+	if pref.Items == nil {
+		pref.Items = []*appbsky.ActorDefs_MutedWord{}
+	}
+	b, err := json.Marshal(pref)
+	if err != nil {
+		return err
+	}
+	if i < 0 {
+		p.Preferences = append(p.Preferences, b)
+	} else {
+		p.Preferences[i] = b
+	}
+	return nil
+}
+
+// getMutedWords returns the user's muted words. It only reads preferences.
+func getMutedWords(ctx context.Context, c *xrpc.Client) ([]*appbsky.ActorDefs_MutedWord, error) {
+	var prefs rawPreferences
+	if err := c.Do(ctx, xrpc.Query, "", "app.bsky.actor.getPreferences", nil, nil, &prefs); err != nil {
+		return nil, fmt.Errorf("error getting preferences: %w", err)
+	}
+	pref, _, err := prefs.mutedWords()
+	if err != nil {
+		return nil, err
+	}
+	return pref.Items, nil
+}
+
+// normalizeMutedWord returns word the way Bluesky stores it: trimmed, and
+// without a leading # since tags are matched without one.
+func normalizeMutedWord(word string) string {
+	word = strings.TrimSpace(word)
+	word = strings.TrimPrefix(word, "#")
+	word = strings.TrimPrefix(word, "＃")
+	return strings.TrimSpace(word)
+}
+
+// upsertMutedWord adds a muted word, or replaces the options of an existing
+// one with the same value. It returns the stored word.
+func upsertMutedWord(ctx context.Context, c *xrpc.Client, word *appbsky.ActorDefs_MutedWord) (*appbsky.ActorDefs_MutedWord, bool, error) {
+	updated := false
+	err := updatePreferences(ctx, c, func(prefs *rawPreferences) error {
+		pref, i, err := prefs.mutedWords()
+		if err != nil {
+			return err
+		}
+		idx := slices.IndexFunc(pref.Items, func(w *appbsky.ActorDefs_MutedWord) bool {
+			return w != nil && strings.EqualFold(w.Value, word.Value)
+		})
+		if idx >= 0 {
+			word.Id = pref.Items[idx].Id
+			pref.Items[idx] = word
+			updated = true
+		} else {
+			id := mutedWordIds.Next().String()
+			word.Id = &id
+			pref.Items = append(pref.Items, word)
+		}
+		return prefs.setMutedWords(pref, i)
+	})
+	return word, updated, err
+}
+
+// removeMutedWord removes the muted word with the given value or ID.
+func removeMutedWord(ctx context.Context, c *xrpc.Client, valueOrId string) error {
+	return updatePreferences(ctx, c, func(prefs *rawPreferences) error {
+		pref, i, err := prefs.mutedWords()
+		if err != nil {
+			return err
+		}
+		value := normalizeMutedWord(valueOrId)
+		n := len(pref.Items)
+		pref.Items = slices.DeleteFunc(pref.Items, func(w *appbsky.ActorDefs_MutedWord) bool {
+			return w != nil && (strings.EqualFold(w.Value, value) || render.StringOrEmpty(w.Id) == valueOrId)
+		})
+		if len(pref.Items) == n {
+			return fmt.Errorf("no muted word %q found", valueOrId)
+		}
+		return prefs.setMutedWords(pref, i)
+	})
+}
+
+// mutedWordExpiry returns the time a word muted for the given number of hours
+// expires at.
+func mutedWordExpiry(hours float64) string {
+	t := time.Now().Add(time.Duration(hours * float64(time.Hour))).UTC()
+	return t.Format(syntax.AtprotoDatetimeLayout)
+}
+
+// muteThreadRoot returns the root of the thread uri is part of, since threads
+// are muted by their root post.
+func muteThreadRoot(ctx context.Context, c *xrpc.Client, uri string) (string, error) {
+	p, err := getPostView(ctx, c, uri)
+	if err != nil {
+		return "", err
+	}
+	if p.Record != nil {
+		if fp, ok := p.Record.Val.(*appbsky.FeedPost); ok && fp.Reply != nil && fp.Reply.Root != nil {
+			return fp.Reply.Root.Uri, nil
+		}
+	}
+	return p.Uri, nil
+}
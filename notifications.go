@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	appbsky "github.com/bluesky-social/indigo/api/bsky"
+	xrpc "github.com/bluesky-social/indigo/xrpc"
+
+	"saturnvi/bsky-mcp/internal/render"
+)
+
+// notificationReasons are the notification reasons known at the time of
+// writing. Others are still rendered, just generically.
+var notificationReasons = []string{
+	"like",
+	"repost",
+	"follow",
+	"mention",
+	"reply",
+	"quote",
+	"starterpack-joined",
+	"verified",
+	"unverified",
+	"like-via-repost",
+	"repost-via-repost",
+	"subscribed-post",
+}
+
+// hydrateNotificationSubjects fetches every post the notifications refer to
+// in batched getPosts calls.
+func hydrateNotificationSubjects(ctx context.Context, c *xrpc.Client, notifications []*appbsky.NotificationListNotifications_Notification) render.PostCache {
+	var uris []string
+	seen := map[string]bool{}
+	for _, n := range notifications {
+		uri := render.NotificationPostSubject(n)
+		if uri != "" && !seen[uri] {
+			seen[uri] = true
+			uris = append(uris, uri)
+		}
+	}
+
+	pc := render.PostCache{}
+	if len(uris) == 0 {
+		return pc
+	}
+	posts, err := getPosts(ctx, c, uris)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error getting notification subjects:", err)
+		return pc
+	}
+	for _, p := range posts {
+		if p.Record != nil {
+			pc[p.Uri] = p
+		}
+	}
+	return pc
+}
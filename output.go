@@ -0,0 +1,923 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	appbsky "github.com/bluesky-social/indigo/api/bsky"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"saturnvi/bsky-mcp/internal/render"
+)
+
+// The types here are what read tools return as structured content, and what
+// their output schemas are generated from. Field names are part of the tool
+// interface, so don't rename them. Lists are never nil, so that they encode
+// as [] rather than null.
+
+type actorOutput struct {
+	Did         string `json:"did"`
+	Handle      string `json:"handle"`
+	DisplayName string `json:"displayName,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type profileOutput struct {
+	Actor          actorOutput `json:"actor"`
+	Verified       bool        `json:"verified"`
+	FollowersCount int64       `json:"followersCount"`
+	FollowsCount   int64       `json:"followsCount"`
+	PostsCount     int64       `json:"postsCount"`
+	Pronouns       []string    `json:"pronouns"`
+}
+
+type facetOutput struct {
+	Type      string `json:"type" jsonschema:"enum=link,enum=mention,enum=tag"`
+	ByteStart int64  `json:"byteStart"`
+	ByteEnd   int64  `json:"byteEnd"`
+	Value     string `json:"value"` // the URI, mentioned DID, or tag
+}
+
+type postOutput struct {
+	Uri         string        `json:"uri"`
+	Cid         string        `json:"cid"`
+	Author      actorOutput   `json:"author"`
+	Text        string        `json:"text"`
+	CreatedAt   string        `json:"createdAt"`
+	IndexedAt   string        `json:"indexedAt"`
+	LikeCount   int64         `json:"likeCount"`
+	RepostCount int64         `json:"repostCount"`
+	QuoteCount  int64         `json:"quoteCount"`
+	ReplyCount  int64         `json:"replyCount"`
+	Facets      []facetOutput `json:"facets"`
+	ReplyParent string        `json:"replyParent,omitempty"`
+	ReplyRoot   string        `json:"replyRoot,omitempty"`
+	RepostedBy  *actorOutput  `json:"repostedBy,omitempty"`
+	Pinned      bool          `json:"pinned,omitempty"`
+	Embed       *embedOutput  `json:"embed,omitempty"`
+}
+
+type imageOutput struct {
+	Alt      string `json:"alt"`
+	Thumb    string `json:"thumb"`
+	Fullsize string `json:"fullsize"`
+	Width    int64  `json:"width,omitempty"`
+	Height   int64  `json:"height,omitempty"`
+}
+
+type videoOutput struct {
+	Alt       string `json:"alt,omitempty"`
+	Playlist  string `json:"playlist"`
+	Thumbnail string `json:"thumbnail,omitempty"`
+}
+
+type externalOutput struct {
+	Uri         string `json:"uri"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Thumb       string `json:"thumb,omitempty"`
+}
+
+// embeddedRecordOutput is a quoted post or other embedded record. Quoted
+// posts carry their media but not records they quote in turn.
+type embeddedRecordOutput struct {
+	Uri      string          `json:"uri"`
+	Type     string          `json:"type" jsonschema:"enum=post,enum=notFound,enum=blocked,enum=detached,enum=feed,enum=list,enum=labeler,enum=starterPack,enum=unknown"`
+	Author   *actorOutput    `json:"author,omitempty"`
+	Text     string          `json:"text,omitempty"`
+	Name     string          `json:"name,omitempty"` // of a feed, list or starter pack
+	Images   []imageOutput   `json:"images,omitempty"`
+	Video    *videoOutput    `json:"video,omitempty"`
+	External *externalOutput `json:"external,omitempty"`
+}
+
+type embedOutput struct {
+	Images   []imageOutput         `json:"images,omitempty"`
+	Video    *videoOutput          `json:"video,omitempty"`
+	External *externalOutput       `json:"external,omitempty"`
+	Record   *embeddedRecordOutput `json:"record,omitempty"`
+}
+
+type feedOutput struct {
+	Name    string       `json:"name,omitempty"`
+	Cursor  string       `json:"cursor,omitempty"`
+	Posts   []postOutput `json:"posts"`
+	Missing []string     `json:"missing,omitempty"` // requested URIs that weren't found
+}
+
+type actorsOutput struct {
+	Subject *actorOutput  `json:"subject,omitempty"`
+	Cursor  string        `json:"cursor,omitempty"`
+	Actors  []actorOutput `json:"actors"`
+}
+
+type likeOutput struct {
+	Actor     actorOutput `json:"actor"`
+	CreatedAt string      `json:"createdAt"`
+}
+
+type likesOutput struct {
+	Uri    string       `json:"uri"`
+	Cursor string       `json:"cursor,omitempty"`
+	Likes  []likeOutput `json:"likes"`
+}
+
+type notificationOutput struct {
+	Uri           string      `json:"uri"`
+	Reason        string      `json:"reason"`
+	Author        actorOutput `json:"author"`
+	IndexedAt     string      `json:"indexedAt"`
+	IsRead        bool        `json:"isRead"`
+	ReasonSubject string      `json:"reasonSubject,omitempty"`
+	SubjectText   string      `json:"subjectText,omitempty"` // text of the post the notification is about
+	Text          string      `json:"text,omitempty"`        // text of the post that caused the notification
+}
+
+type notificationsOutput struct {
+	Cursor        string               `json:"cursor,omitempty"`
+	SeenAt        string               `json:"seenAt,omitempty"`
+	Notifications []notificationOutput `json:"notifications"`
+}
+
+type unreadCountOutput struct {
+	Count int64 `json:"count"`
+}
+
+// threadItemOutput is one post of a thread. Depth is 0 for parents and the
+// requested post, and counts up from 0 for replies.
+type threadItemOutput struct {
+	Uri    string      `json:"uri"`
+	Status string      `json:"status" jsonschema:"enum=ok,enum=notFound,enum=blocked,enum=unknown"`
+	Depth  int         `json:"depth"`
+	Mine   bool        `json:"mine,omitempty"`
+	Post   *postOutput `json:"post,omitempty"`
+}
+
+type threadOutput struct {
+	Parents []threadItemOutput `json:"parents"` // starting from the root
+	Post    threadItemOutput   `json:"post"`
+	Replies []threadItemOutput `json:"replies"` // depth first
+}
+
+type feedGeneratorOutput struct {
+	Uri         string `json:"uri"`
+	DisplayName string `json:"displayName"`
+	Description string `json:"description,omitempty"`
+	LikeCount   int64  `json:"likeCount"`
+	IsOnline    bool   `json:"isOnline"`
+}
+
+type savedFeedsOutput struct {
+	Feeds []feedGeneratorOutput `json:"feeds"`
+}
+
+type trendOutput struct {
+	Rank        int    `json:"rank"`
+	DisplayName string `json:"displayName"`
+	Category    string `json:"category,omitempty"`
+	PostCount   int64  `json:"postCount"`
+	StartedAt   string `json:"startedAt"`
+	Status      string `json:"status,omitempty"`
+	FeedUri     string `json:"feedUri"`
+}
+
+type trendsOutput struct {
+	Trends []trendOutput `json:"trends"`
+}
+
+type listOutput struct {
+	Uri         string      `json:"uri"`
+	Name        string      `json:"name"`
+	Purpose     string      `json:"purpose"`
+	Description string      `json:"description,omitempty"`
+	Creator     actorOutput `json:"creator"`
+	MemberCount int64       `json:"memberCount"`
+}
+
+type listsOutput struct {
+	Cursor string       `json:"cursor,omitempty"`
+	Lists  []listOutput `json:"lists"`
+}
+
+type listMemberOutput struct {
+	ItemUri string      `json:"itemUri"` // the app.bsky.graph.listitem record
+	Actor   actorOutput `json:"actor"`
+}
+
+type listMembersOutput struct {
+	List    listOutput         `json:"list"`
+	Cursor  string             `json:"cursor,omitempty"`
+	Members []listMemberOutput `json:"members"`
+}
+
+type mutedWordOutput struct {
+	Id               string   `json:"id"`
+	Value            string   `json:"value"`
+	Targets          []string `json:"targets"`
+	ExcludeFollowing bool     `json:"excludeFollowing"`
+	ExpiresAt        string   `json:"expiresAt,omitempty"`
+	Expired          bool     `json:"expired,omitempty"`
+}
+
+type mutedWordsOutput struct {
+	Words []mutedWordOutput `json:"words"`
+}
+
+// withOutputFormat adds the format parameter to a read tool.
+func withOutputFormat() mcp.ToolOption {
+	return mcp.WithString("format",
+		mcp.Description("Optional format of the text output: 'text' for prose, 'json' for the same data as the structured output, or 'markdown'. Default is 'text'."),
+		mcp.DefaultString("text"),
+		mcp.Enum("text", "json", "markdown"),
+	)
+}
+
+type markdowner interface {
+	markdown() string
+}
+
+// formatResult builds the result of a read tool. out is always attached as
+// structured content; the text content is text, out as JSON, or out as
+// markdown depending on the format parameter.
+func formatResult(request mcp.CallToolRequest, out markdowner, text string) *mcp.CallToolResult {
+	switch request.GetString("format", "text") {
+	case "json":
+		b, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error encoding output: %s", err))
+		}
+		text = string(b)
+	case "markdown":
+		text = out.markdown()
+	}
+	return mcp.NewToolResultStructured(out, text)
+}
+
+func actorFromBasic(a *appbsky.ActorDefs_ProfileViewBasic) actorOutput {
+	if a == nil {
+		return actorOutput{}
+	}
+	return actorOutput{Did: a.Did, Handle: a.Handle, DisplayName: render.StringOrEmpty(a.DisplayName)}
+}
+
+func actorFromView(a *appbsky.ActorDefs_ProfileView) actorOutput {
+	if a == nil {
+		return actorOutput{}
+	}
+	return actorOutput{
+		Did:         a.Did,
+		Handle:      a.Handle,
+		DisplayName: render.StringOrEmpty(a.DisplayName),
+		Description: render.StringOrEmpty(a.Description),
+	}
+}
+
+func actorsFromViews(actors []*appbsky.ActorDefs_ProfileView) []actorOutput {
+	out := []actorOutput{}
+	for _, a := range actors {
+		if a != nil {
+			out = append(out, actorFromView(a))
+		}
+	}
+	return out
+}
+
+func profileFromDetailed(profile *appbsky.ActorDefs_ProfileViewDetailed, pronouns []string) profileOutput {
+	out := profileOutput{Pronouns: []string{}}
+	if profile == nil {
+		return out
+	}
+	out.Actor = actorOutput{
+		Did:         profile.Did,
+		Handle:      profile.Handle,
+		DisplayName: render.StringOrEmpty(profile.DisplayName),
+		Description: render.StringOrEmpty(profile.Description),
+	}
+	if v := profile.Verification; v != nil {
+		out.Verified = v.TrustedVerifierStatus == "valid" || v.VerifiedStatus == "valid"
+	}
+	out.FollowersCount = render.CountOrZero(profile.FollowersCount)
+	out.FollowsCount = render.CountOrZero(profile.FollowsCount)
+	out.PostsCount = render.CountOrZero(profile.PostsCount)
+	out.Pronouns = append(out.Pronouns, pronouns...)
+	return out
+}
+
+func postFromView(p *appbsky.FeedDefs_PostView) postOutput {
+	out := postOutput{
+		Uri:         p.Uri,
+		Cid:         p.Cid,
+		Author:      actorFromBasic(p.Author),
+		CreatedAt:   p.IndexedAt,
+		IndexedAt:   p.IndexedAt,
+		LikeCount:   render.CountOrZero(p.LikeCount),
+		RepostCount: render.CountOrZero(p.RepostCount),
+		QuoteCount:  render.CountOrZero(p.QuoteCount),
+		ReplyCount:  render.CountOrZero(p.ReplyCount),
+		Facets:      []facetOutput{},
+	}
+	var fp *appbsky.FeedPost
+	if p.Record != nil {
+		fp, _ = p.Record.Val.(*appbsky.FeedPost)
+	}
+	if fp == nil {
+		return out
+	}
+
+	out.Embed = embedFromView(render.EmbedViewFromPost(p.Embed))
+	out.Text = fp.Text
+	out.CreatedAt = fp.CreatedAt
+	if fp.Reply != nil {
+		if fp.Reply.Parent != nil {
+			out.ReplyParent = fp.Reply.Parent.Uri
+		}
+		if fp.Reply.Root != nil {
+			out.ReplyRoot = fp.Reply.Root.Uri
+		}
+	}
+	for _, facet := range fp.Facets {
+		if facet == nil || facet.Index == nil {
+			continue
+		}
+		for _, feature := range facet.Features {
+			f := facetOutput{ByteStart: facet.Index.ByteStart, ByteEnd: facet.Index.ByteEnd}
+			switch {
+			case feature == nil:
+				continue
+			case feature.RichtextFacet_Link != nil:
+				f.Type, f.Value = "link", feature.RichtextFacet_Link.Uri
+			case feature.RichtextFacet_Mention != nil:
+				f.Type, f.Value = "mention", feature.RichtextFacet_Mention.Did
+			case feature.RichtextFacet_Tag != nil:
+				f.Type, f.Value = "tag", feature.RichtextFacet_Tag.Tag
+			default:
+				continue
+			}
+			out.Facets = append(out.Facets, f)
+		}
+	}
+	return out
+}
+
+func embedFromView(e render.EmbedView) *embedOutput {
+	out := &embedOutput{}
+	if e.RecordWithMedia != nil {
+		if media := embedFromView(render.EmbedViewFromMedia(e.RecordWithMedia.Media)); media != nil {
+			out = media
+		}
+		e.Record = e.RecordWithMedia.Record
+	}
+	if e.Images != nil {
+		for _, img := range e.Images.Images {
+			if img == nil {
+				continue
+			}
+			io := imageOutput{Alt: img.Alt, Thumb: img.Thumb, Fullsize: img.Fullsize}
+			if img.AspectRatio != nil {
+				io.Width, io.Height = img.AspectRatio.Width, img.AspectRatio.Height
+			}
+			out.Images = append(out.Images, io)
+		}
+	}
+	if e.Video != nil {
+		out.Video = &videoOutput{
+			Alt:       render.StringOrEmpty(e.Video.Alt),
+			Playlist:  e.Video.Playlist,
+			Thumbnail: render.StringOrEmpty(e.Video.Thumbnail),
+		}
+	}
+	if e.External != nil && e.External.External != nil {
+		ext := e.External.External
+		out.External = &externalOutput{Uri: ext.Uri, Title: ext.Title, Description: ext.Description, Thumb: render.StringOrEmpty(ext.Thumb)}
+	}
+	if e.Record != nil && e.Record.Record != nil {
+		out.Record = embeddedRecordFromView(e.Record.Record)
+	}
+	if out.Images == nil && out.Video == nil && out.External == nil && out.Record == nil {
+		return nil
+	}
+	return out
+}
+
+func embeddedRecordFromView(r *appbsky.EmbedRecord_View_Record) *embeddedRecordOutput {
+	switch {
+	case r.EmbedRecord_ViewRecord != nil:
+		q := r.EmbedRecord_ViewRecord
+		author := actorFromBasic(q.Author)
+		out := &embeddedRecordOutput{Uri: q.Uri, Type: "post", Author: &author}
+		if q.Value != nil {
+			if fp, ok := q.Value.Val.(*appbsky.FeedPost); ok {
+				out.Text = fp.Text
+			}
+		}
+		for _, e := range q.Embeds {
+			view := render.EmbedViewFromQuote(e)
+			view.Record = nil
+			if media := embedFromView(view); media != nil {
+				out.Images = append(out.Images, media.Images...)
+				out.Video = media.Video
+				out.External = media.External
+			}
+		}
+		return out
+	case r.EmbedRecord_ViewNotFound != nil:
+		return &embeddedRecordOutput{Uri: r.EmbedRecord_ViewNotFound.Uri, Type: "notFound"}
+	case r.EmbedRecord_ViewBlocked != nil:
+		return &embeddedRecordOutput{Uri: r.EmbedRecord_ViewBlocked.Uri, Type: "blocked"}
+	case r.EmbedRecord_ViewDetached != nil:
+		return &embeddedRecordOutput{Uri: r.EmbedRecord_ViewDetached.Uri, Type: "detached"}
+	case r.FeedDefs_GeneratorView != nil:
+		g := r.FeedDefs_GeneratorView
+		creator := actorFromView(g.Creator)
+		return &embeddedRecordOutput{Uri: g.Uri, Type: "feed", Author: &creator, Name: g.DisplayName}
+	case r.GraphDefs_ListView != nil:
+		l := r.GraphDefs_ListView
+		creator := actorFromView(l.Creator)
+		return &embeddedRecordOutput{Uri: l.Uri, Type: "list", Author: &creator, Name: l.Name}
+	case r.LabelerDefs_LabelerView != nil:
+		l := r.LabelerDefs_LabelerView
+		creator := actorFromView(l.Creator)
+		return &embeddedRecordOutput{Uri: l.Uri, Type: "labeler", Author: &creator}
+	case r.GraphDefs_StarterPackViewBasic != nil:
+		sp := r.GraphDefs_StarterPackViewBasic
+		creator := actorFromBasic(sp.Creator)
+		return &embeddedRecordOutput{Uri: sp.Uri, Type: "starterPack", Author: &creator, Name: render.StarterPackName(sp)}
+	}
+	return &embeddedRecordOutput{Type: "unknown"}
+}
+
+func feedFromFeedViews(name, cursor string, posts []*appbsky.FeedDefs_FeedViewPost) feedOutput {
+	out := feedOutput{Name: name, Cursor: cursor, Posts: []postOutput{}}
+	for _, post := range posts {
+		if post == nil || post.Post == nil {
+			continue
+		}
+		p := postFromView(post.Post)
+		if post.Reason != nil {
+			if post.Reason.FeedDefs_ReasonPin != nil {
+				p.Pinned = true
+			}
+			if repost := post.Reason.FeedDefs_ReasonRepost; repost != nil {
+				by := actorFromBasic(repost.By)
+				p.RepostedBy = &by
+			}
+		}
+		out.Posts = append(out.Posts, p)
+	}
+	return out
+}
+
+func feedFromPostViews(cursor string, posts []*appbsky.FeedDefs_PostView) feedOutput {
+	out := feedOutput{Cursor: cursor, Posts: []postOutput{}}
+	for _, p := range posts {
+		if p != nil {
+			out.Posts = append(out.Posts, postFromView(p))
+		}
+	}
+	return out
+}
+
+func notificationsFromAPI(r *appbsky.NotificationListNotifications_Output, notifications []*appbsky.NotificationListNotifications_Notification, pc render.PostCache) notificationsOutput {
+	out := notificationsOutput{
+		Cursor:        render.CursorOrEmpty(r.Cursor),
+		SeenAt:        render.StringOrEmpty(r.SeenAt),
+		Notifications: []notificationOutput{},
+	}
+	for _, n := range notifications {
+		no := notificationOutput{
+			Uri:           n.Uri,
+			Reason:        n.Reason,
+			Author:        actorFromView(n.Author),
+			IndexedAt:     n.IndexedAt,
+			IsRead:        n.IsRead,
+			ReasonSubject: render.StringOrEmpty(n.ReasonSubject),
+		}
+		if subj := render.NotificationPostSubject(n); subj != "" {
+			no.SubjectText = pc.Text(subj)
+		}
+		if post := render.NotificationPost(n); post != nil {
+			no.Text = post.Text
+		}
+		out.Notifications = append(out.Notifications, no)
+	}
+	return out
+}
+
+func threadFromAPI(thread *appbsky.FeedGetPostThread_Output_Thread, viewerDid string) threadOutput {
+	out := threadOutput{Parents: []threadItemOutput{}, Replies: []threadItemOutput{}}
+	anchor := render.ThreadNodeFromThread(thread)
+	out.Post = threadItemFromNode(anchor, 0, viewerDid)
+	if anchor.Post == nil {
+		return out
+	}
+
+	for p := anchor.Post.Parent; p != nil; {
+		node := render.ThreadNodeFromParent(p)
+		out.Parents = append([]threadItemOutput{threadItemFromNode(node, 0, viewerDid)}, out.Parents...)
+		if node.Post == nil {
+			break
+		}
+		p = node.Post.Parent
+	}
+
+	var walk func(replies []*appbsky.FeedDefs_ThreadViewPost_Replies_Elem, depth int)
+	walk = func(replies []*appbsky.FeedDefs_ThreadViewPost_Replies_Elem, depth int) {
+		for _, reply := range replies {
+			node := render.ThreadNodeFromReply(reply)
+			out.Replies = append(out.Replies, threadItemFromNode(node, depth, viewerDid))
+			if node.Post != nil {
+				walk(node.Post.Replies, depth+1)
+			}
+		}
+	}
+	walk(anchor.Post.Replies, 0)
+	return out
+}
+
+// posts returns the posts of the thread that could be loaded, in order.
+func (t threadOutput) posts() []postOutput {
+	var posts []postOutput
+	for _, item := range slices.Concat(t.Parents, []threadItemOutput{t.Post}, t.Replies) {
+		if item.Post != nil {
+			posts = append(posts, *item.Post)
+		}
+	}
+	return posts
+}
+
+func threadItemFromNode(node render.ThreadNode, depth int, viewerDid string) threadItemOutput {
+	item := threadItemOutput{Depth: depth, Status: "unknown"}
+	switch {
+	case node.Post != nil && node.Post.Post != nil:
+		p := postFromView(node.Post.Post)
+		item.Uri = p.Uri
+		item.Status = "ok"
+		item.Mine = p.Author.Did == viewerDid
+		item.Post = &p
+	case node.NotFound != nil:
+		item.Uri = node.NotFound.Uri
+		item.Status = "notFound"
+	case node.Blocked != nil:
+		item.Uri = node.Blocked.Uri
+		item.Status = "blocked"
+	}
+	return item
+}
+
+func listFromView(l *appbsky.GraphDefs_ListView) listOutput {
+	if l == nil {
+		return listOutput{}
+	}
+	return listOutput{
+		Uri:         l.Uri,
+		Name:        l.Name,
+		Purpose:     render.ListPurposeName(l.Purpose),
+		Description: render.StringOrEmpty(l.Description),
+		Creator:     actorFromView(l.Creator),
+		MemberCount: render.CountOrZero(l.ListItemCount),
+	}
+}
+
+func listsFromViews(cursor string, lists []*appbsky.GraphDefs_ListView) listsOutput {
+	out := listsOutput{Cursor: cursor, Lists: []listOutput{}}
+	for _, l := range lists {
+		if l != nil {
+			out.Lists = append(out.Lists, listFromView(l))
+		}
+	}
+	return out
+}
+
+func listMembersFromAPI(r *appbsky.GraphGetList_Output) listMembersOutput {
+	out := listMembersOutput{List: listFromView(r.List), Cursor: render.CursorOrEmpty(r.Cursor), Members: []listMemberOutput{}}
+	for _, item := range r.Items {
+		if item != nil {
+			out.Members = append(out.Members, listMemberOutput{ItemUri: item.Uri, Actor: actorFromView(item.Subject)})
+		}
+	}
+	return out
+}
+
+func mutedWordsFromAPI(words []*appbsky.ActorDefs_MutedWord) mutedWordsOutput {
+	out := mutedWordsOutput{Words: []mutedWordOutput{}}
+	for _, w := range words {
+		if w == nil {
+			continue
+		}
+		mw := mutedWordOutput{
+			Id:               render.StringOrEmpty(w.Id),
+			Value:            w.Value,
+			Targets:          []string{},
+			ExcludeFollowing: render.StringOrEmpty(w.ActorTarget) == "exclude-following",
+			ExpiresAt:        render.StringOrEmpty(w.ExpiresAt),
+			Expired:          render.MutedWordExpired(w),
+		}
+		for _, t := range w.Targets {
+			if t != nil {
+				mw.Targets = append(mw.Targets, *t)
+			}
+		}
+		out.Words = append(out.Words, mw)
+	}
+	return out
+}
+
+func feedGeneratorFromAPI(feedGen *appbsky.FeedGetFeedGenerator_Output) feedGeneratorOutput {
+	if feedGen == nil || feedGen.View == nil {
+		return feedGeneratorOutput{}
+	}
+	return feedGeneratorOutput{
+		Uri:         feedGen.View.Uri,
+		DisplayName: feedGen.View.DisplayName,
+		Description: render.StringOrEmpty(feedGen.View.Description),
+		LikeCount:   render.CountOrZero(feedGen.View.LikeCount),
+		IsOnline:    feedGen.IsOnline,
+	}
+}
+
+func (a actorOutput) markdown() string {
+	if a.Did == "" {
+		return "Unknown user"
+	}
+	name := a.DisplayName
+	if strings.TrimSpace(name) == "" {
+		name = a.Handle
+	}
+	return fmt.Sprintf("**%s** (@%s, `%s`)", name, a.Handle, a.Did)
+}
+
+func (p profileOutput) markdown() string {
+	str := fmt.Sprintf("## %s\n\n", p.Actor.markdown())
+	if p.Actor.Description != "" {
+		str += quoteMarkdown(p.Actor.Description) + "\n"
+	}
+	verified := "No"
+	if p.Verified {
+		verified = "Yes"
+	}
+	str += fmt.Sprintf("- Verified: %s\n", verified)
+	str += fmt.Sprintf("- Followers: %d\n", p.FollowersCount)
+	str += fmt.Sprintf("- Following: %d\n", p.FollowsCount)
+	str += fmt.Sprintf("- Posts: %d\n", p.PostsCount)
+	if len(p.Pronouns) > 0 {
+		str += fmt.Sprintf("- Pronouns: %s\n", strings.Join(p.Pronouns, ", "))
+	}
+	return str
+}
+
+func (p postOutput) markdown() string {
+	str := ""
+	switch {
+	case p.Pinned:
+		str += "Pinned post by "
+	case p.RepostedBy != nil:
+		str += fmt.Sprintf("Reposted by %s\n\n", p.RepostedBy.markdown())
+	}
+	str += fmt.Sprintf("%s · %s\n\n", p.Author.markdown(), p.CreatedAt)
+	if p.ReplyParent != "" {
+		str += fmt.Sprintf("In reply to `%s`\n\n", p.ReplyParent)
+	}
+	str += quoteMarkdown(p.Text) + "\n"
+	if p.Embed != nil {
+		str += p.Embed.markdown() + "\n"
+	}
+	str += fmt.Sprintf("%d likes · %d reposts · %d quotes · %d replies · `%s`\n", p.LikeCount, p.RepostCount, p.QuoteCount, p.ReplyCount, p.Uri)
+	return str
+}
+
+func (f feedOutput) markdown() string {
+	str := ""
+	if f.Name != "" {
+		str += fmt.Sprintf("# %s\n\n", f.Name)
+	}
+	for _, p := range f.Posts {
+		str += p.markdown() + "\n---\n\n"
+	}
+	if len(f.Missing) > 0 {
+		str += "Not found (deleted, blocked, or invalid):\n"
+		for _, uri := range f.Missing {
+			str += fmt.Sprintf("- `%s`\n", uri)
+		}
+		str += "\n"
+	}
+	return str + cursorMarkdown(f.Cursor)
+}
+
+func (a actorsOutput) markdown() string {
+	str := ""
+	if a.Subject != nil {
+		str += fmt.Sprintf("# %s\n\n", a.Subject.markdown())
+	}
+	for _, actor := range a.Actors {
+		str += "- " + actor.markdown()
+		if actor.Description != "" {
+			str += ": " + strings.Join(strings.Fields(actor.Description), " ")
+		}
+		str += "\n"
+	}
+	return str + "\n" + cursorMarkdown(a.Cursor)
+}
+
+func (l likesOutput) markdown() string {
+	str := fmt.Sprintf("# Likes of `%s`\n\n", l.Uri)
+	for _, like := range l.Likes {
+		str += fmt.Sprintf("- %s, %s\n", like.Actor.markdown(), like.CreatedAt)
+	}
+	return str + "\n" + cursorMarkdown(l.Cursor)
+}
+
+func (n notificationsOutput) markdown() string {
+	str := "# Notifications\n\n"
+	if n.SeenAt != "" {
+		str += fmt.Sprintf("Last seen at %s\n\n", n.SeenAt)
+	}
+	for _, no := range n.Notifications {
+		unread := ""
+		if !no.IsRead {
+			unread = " (unread)"
+		}
+		str += fmt.Sprintf("- **%s**%s from %s at %s: `%s`\n", no.Reason, unread, no.Author.markdown(), no.IndexedAt, no.Uri)
+		if no.ReasonSubject != "" {
+			str += fmt.Sprintf("  - Subject: `%s`\n", no.ReasonSubject)
+		}
+		if no.SubjectText != "" {
+			str += fmt.Sprintf("  - Subject text: %s\n", strings.Join(strings.Fields(no.SubjectText), " "))
+		}
+		if no.Text != "" {
+			str += fmt.Sprintf("  - Text: %s\n", strings.Join(strings.Fields(no.Text), " "))
+		}
+	}
+	return str + "\n" + cursorMarkdown(n.Cursor)
+}
+
+func (u unreadCountOutput) markdown() string {
+	return fmt.Sprintf("**%d** unread notifications\n", u.Count)
+}
+
+func (t threadOutput) markdown() string {
+	str := ""
+	if len(t.Parents) > 0 {
+		str += "## Parents\n\n"
+		for _, item := range t.Parents {
+			str += item.markdown() + "\n"
+		}
+	}
+	str += "## Post\n\n" + t.Post.markdown() + "\n"
+	if len(t.Replies) > 0 {
+		str += "## Replies\n\n"
+		for _, item := range t.Replies {
+			str += item.markdown() + "\n"
+		}
+	}
+	return str
+}
+
+func (item threadItemOutput) markdown() string {
+	str := ""
+	switch item.Status {
+	case "ok":
+		if item.Mine {
+			str += "(Your post) "
+		}
+		str += item.Post.markdown()
+	case "notFound":
+		str += fmt.Sprintf("Post not found, it may have been deleted: `%s`\n", item.Uri)
+	case "blocked":
+		str += fmt.Sprintf("Post hidden due to a block: `%s`\n", item.Uri)
+	default:
+		str += "Unknown thread item\n"
+	}
+	return render.Indent(str, strings.Repeat("> ", item.Depth))
+}
+
+func (s savedFeedsOutput) markdown() string {
+	str := "# Saved feeds\n\n"
+	for _, f := range s.Feeds {
+		status := "online"
+		if !f.IsOnline {
+			status = "offline"
+		}
+		str += fmt.Sprintf("- **%s** (%s, %d likes): `%s`", f.DisplayName, status, f.LikeCount, f.Uri)
+		if f.Description != "" {
+			str += " — " + strings.Join(strings.Fields(f.Description), " ")
+		}
+		str += "\n"
+	}
+	return str
+}
+
+func (t trendsOutput) markdown() string {
+	str := "# Trending\n\n"
+	for _, trend := range t.Trends {
+		str += fmt.Sprintf("%d. **%s** (%s, %d posts, started %s, %s): `%s`\n", trend.Rank, trend.DisplayName, trend.Category, trend.PostCount, trend.StartedAt, trend.Status, trend.FeedUri)
+	}
+	return str
+}
+
+func (l listOutput) markdown() string {
+	str := fmt.Sprintf("**%s** (%s list by %s, %d members): `%s`", l.Name, l.Purpose, l.Creator.markdown(), l.MemberCount, l.Uri)
+	if l.Description != "" {
+		str += " — " + strings.Join(strings.Fields(l.Description), " ")
+	}
+	return str
+}
+
+func (l listsOutput) markdown() string {
+	str := "# Lists\n\n"
+	for _, list := range l.Lists {
+		str += "- " + list.markdown() + "\n"
+	}
+	return str + "\n" + cursorMarkdown(l.Cursor)
+}
+
+func (l listMembersOutput) markdown() string {
+	str := fmt.Sprintf("# %s\n\n", l.List.markdown())
+	for _, m := range l.Members {
+		str += fmt.Sprintf("- %s (item `%s`)\n", m.Actor.markdown(), m.ItemUri)
+	}
+	return str + "\n" + cursorMarkdown(l.Cursor)
+}
+
+func (m mutedWordsOutput) markdown() string {
+	str := "# Muted words\n\n"
+	for _, w := range m.Words {
+		str += fmt.Sprintf("- **%s** in %s", w.Value, strings.Join(w.Targets, ", "))
+		if w.ExcludeFollowing {
+			str += ", except from people you follow"
+		}
+		switch {
+		case w.Expired:
+			str += fmt.Sprintf(", expired %s", w.ExpiresAt)
+		case w.ExpiresAt != "":
+			str += fmt.Sprintf(", until %s", w.ExpiresAt)
+		}
+		str += fmt.Sprintf(" (`%s`)\n", w.Id)
+	}
+	return str
+}
+
+func (e embedOutput) markdown() string {
+	str := markdownFromMedia(e.Images, e.Video, e.External)
+	if r := e.Record; r != nil {
+		switch r.Type {
+		case "post":
+			str += fmt.Sprintf("Quoting %s: `%s`\n\n", r.Author.markdown(), r.Uri)
+			quoted := quoteMarkdown(r.Text)
+			if media := markdownFromMedia(r.Images, r.Video, r.External); media != "" {
+				quoted += "\n" + media
+			}
+			str += render.Indent(quoted, "> ")
+		case "notFound":
+			str += fmt.Sprintf("Quoted post not found: `%s`\n", r.Uri)
+		case "blocked":
+			str += fmt.Sprintf("Quoted post hidden due to a block: `%s`\n", r.Uri)
+		case "detached":
+			str += fmt.Sprintf("Quoted post removed by its author: `%s`\n", r.Uri)
+		case "unknown":
+			str += "Embedded record of an unknown type\n"
+		default:
+			str += fmt.Sprintf("Embedded %s **%s**", r.Type, r.Name)
+			if r.Author != nil {
+				str += " by " + r.Author.markdown()
+			}
+			str += fmt.Sprintf(": `%s`\n", r.Uri)
+		}
+	}
+	return str
+}
+
+func markdownFromMedia(images []imageOutput, video *videoOutput, external *externalOutput) string {
+	str := ""
+	for _, img := range images {
+		str += fmt.Sprintf("![%s](%s)\n", strings.Join(strings.Fields(img.Alt), " "), img.Fullsize)
+	}
+	if video != nil {
+		str += fmt.Sprintf("Video: [%s](%s)\n", strings.Join(strings.Fields(video.Alt), " "), video.Playlist)
+	}
+	if external != nil {
+		str += fmt.Sprintf("Link: [%s](%s)", external.Title, external.Uri)
+		if external.Description != "" {
+			str += " — " + strings.Join(strings.Fields(external.Description), " ")
+		}
+		str += "\n"
+	}
+	return str
+}
+
+// quoteMarkdown renders text as a markdown blockquote.
+func quoteMarkdown(text string) string {
+	if text == "" {
+		return ""
+	}
+	return "> " + strings.ReplaceAll(text, "\n", "\n> ") + "\n"
+}
+
+func cursorMarkdown(cursor string) string {
+	if cursor == "" {
+		return ""
+	}
+	return fmt.Sprintf("Cursor: `%s`\n", cursor)
+}
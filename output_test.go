@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	appbsky "github.com/bluesky-social/indigo/api/bsky"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+
+	"saturnvi/bsky-mcp/internal/render"
+)
+
+// The API leaves out optional fields when they're empty, so these feed the
+// converters views with as little set as the lexicons allow.
+
+func sparsePost(uri string) *appbsky.FeedDefs_PostView {
+	return &appbsky.FeedDefs_PostView{
+		Uri:       uri,
+		Author:    &appbsky.ActorDefs_ProfileViewBasic{Did: "did:plc:alice", Handle: "alice.test"},
+		IndexedAt: "2025-01-01T00:00:00Z",
+		Record:    &lexutil.LexiconTypeDecoder{Val: &appbsky.FeedPost{Text: "hello"}},
+	}
+}
+
+// sparseEmbeds are embeds whose optional members are all left out.
+var sparseEmbeds = []*appbsky.FeedDefs_PostView_Embed{
+	{EmbedImages_View: &appbsky.EmbedImages_View{Images: []*appbsky.EmbedImages_ViewImage{nil}}},
+	{EmbedVideo_View: &appbsky.EmbedVideo_View{}},
+	{EmbedExternal_View: &appbsky.EmbedExternal_View{}},
+	{EmbedRecord_View: &appbsky.EmbedRecord_View{}},
+	{EmbedRecord_View: &appbsky.EmbedRecord_View{Record: &appbsky.EmbedRecord_View_Record{}}},
+	{EmbedRecord_View: &appbsky.EmbedRecord_View{Record: &appbsky.EmbedRecord_View_Record{
+		EmbedRecord_ViewRecord: &appbsky.EmbedRecord_ViewRecord{Uri: "at://did:plc:bob/app.bsky.feed.post/1", Embeds: []*appbsky.EmbedRecord_ViewRecord_Embeds_Elem{nil}},
+	}}},
+	{EmbedRecord_View: &appbsky.EmbedRecord_View{Record: &appbsky.EmbedRecord_View_Record{
+		FeedDefs_GeneratorView: &appbsky.FeedDefs_GeneratorView{Uri: "at://did:plc:bob/app.bsky.feed.generator/1"},
+	}}},
+	{EmbedRecord_View: &appbsky.EmbedRecord_View{Record: &appbsky.EmbedRecord_View_Record{
+		GraphDefs_StarterPackViewBasic: &appbsky.GraphDefs_StarterPackViewBasic{Uri: "at://did:plc:bob/app.bsky.graph.starterpack/1"},
+	}}},
+	{EmbedRecordWithMedia_View: &appbsky.EmbedRecordWithMedia_View{}},
+	{EmbedRecordWithMedia_View: &appbsky.EmbedRecordWithMedia_View{Media: &appbsky.EmbedRecordWithMedia_View_Media{}}},
+}
+
+// assertNoNullLists fails if any list in out encodes as null.
+func assertNoNullLists(t *testing.T, name string, out any) {
+	t.Helper()
+	b, err := json.Marshal(out)
+	if err != nil {
+		t.Fatalf("%s: %s", name, err)
+	}
+	if strings.Contains(string(b), "null") {
+		t.Errorf("%s encodes a null: %s", name, b)
+	}
+}
+
+func TestConvertersSparse(t *testing.T) {
+	noRecord := sparsePost("at://did:plc:alice/app.bsky.feed.post/1")
+	noRecord.Record = nil
+	noAuthor := sparsePost("at://did:plc:alice/app.bsky.feed.post/2")
+	noAuthor.Author = nil
+
+	feedViews := []*appbsky.FeedDefs_FeedViewPost{
+		nil,
+		{},
+		{Post: noRecord, Reason: &appbsky.FeedDefs_FeedViewPost_Reason{}},
+		{Post: noAuthor, Reason: &appbsky.FeedDefs_FeedViewPost_Reason{FeedDefs_ReasonRepost: &appbsky.FeedDefs_ReasonRepost{}}},
+	}
+	for _, embed := range sparseEmbeds {
+		p := sparsePost("at://did:plc:alice/app.bsky.feed.post/3")
+		p.Embed = embed
+		feedViews = append(feedViews, &appbsky.FeedDefs_FeedViewPost{Post: p})
+	}
+
+	feed := feedFromFeedViews("", "", feedViews)
+	assertNoNullLists(t, "feedFromFeedViews", feed)
+	if len(feed.Posts) != len(feedViews)-2 {
+		t.Errorf("feedFromFeedViews() returned %d posts, want %d", len(feed.Posts), len(feedViews)-2)
+	}
+	if p := feed.Posts[1]; p.RepostedBy == nil || p.Author.Did != "" {
+		t.Errorf("feedFromFeedViews() repost by nobody of a post by nobody = %+v", p)
+	}
+	assertNoNullLists(t, "feedFromPostViews", feedFromPostViews("", []*appbsky.FeedDefs_PostView{nil, noRecord}))
+
+	assertNoNullLists(t, "profileFromDetailed(nil)", profileFromDetailed(nil, nil))
+	assertNoNullLists(t, "profileFromDetailed", profileFromDetailed(&appbsky.ActorDefs_ProfileViewDetailed{Did: "did:plc:alice"}, nil))
+	assertNoNullLists(t, "actorsFromViews", actorsFromViews([]*appbsky.ActorDefs_ProfileView{nil}))
+
+	notifications := []*appbsky.NotificationListNotifications_Notification{
+		{Reason: "like"},
+		{Reason: "reply", Record: &lexutil.LexiconTypeDecoder{Val: &appbsky.FeedPost{}}},
+	}
+	assertNoNullLists(t, "notificationsFromAPI", notificationsFromAPI(&appbsky.NotificationListNotifications_Output{}, notifications, render.PostCache{}))
+	assertNoNullLists(t, "notificationsFromAPI with none", notificationsFromAPI(&appbsky.NotificationListNotifications_Output{}, nil, render.PostCache{}))
+
+	thread := threadFromAPI(&appbsky.FeedGetPostThread_Output_Thread{
+		FeedDefs_ThreadViewPost: &appbsky.FeedDefs_ThreadViewPost{
+			Post:    noRecord,
+			Parent:  &appbsky.FeedDefs_ThreadViewPost_Parent{},
+			Replies: []*appbsky.FeedDefs_ThreadViewPost_Replies_Elem{{}},
+		},
+	}, "did:plc:alice")
+	assertNoNullLists(t, "threadFromAPI", thread)
+	assertNoNullLists(t, "threadFromAPI of a missing post", threadFromAPI(&appbsky.FeedGetPostThread_Output_Thread{}, ""))
+	if got := threadFromAPI(&appbsky.FeedGetPostThread_Output_Thread{}, "").Post.Status; got != "unknown" {
+		t.Errorf("threadFromAPI of an unrecognized item has status %q, want %q", got, "unknown")
+	}
+
+	assertNoNullLists(t, "listsFromViews", listsFromViews("", []*appbsky.GraphDefs_ListView{nil, {}}))
+	assertNoNullLists(t, "listMembersFromAPI", listMembersFromAPI(&appbsky.GraphGetList_Output{Items: []*appbsky.GraphDefs_ListItemView{nil, {}}}))
+	assertNoNullLists(t, "mutedWordsFromAPI", mutedWordsFromAPI([]*appbsky.ActorDefs_MutedWord{nil, {Targets: []*string{nil}}}))
+	assertNoNullLists(t, "feedGeneratorFromAPI", feedGeneratorFromAPI(&appbsky.FeedGetFeedGenerator_Output{}))
+}
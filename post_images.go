@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	maxContentImages     = 10        // per tool result
+	maxContentImageBytes = 1_000_000 // per image; thumbnails are usually far smaller
+	maxImageCacheBytes   = 50_000_000
+	imageFetchWorkers    = 4
+)
+
+// thumbnailFetcher downloads post image thumbnails from the CDN.
+var thumbnailFetcher linkFetcher = &httpLinkFetcher{
+	client:        &http.Client{Timeout: 10 * time.Second},
+	maxImageBytes: maxContentImageBytes,
+}
+
+type cachedImage struct {
+	data     []byte
+	mimeType string
+}
+
+// imageCache keeps downloaded thumbnails in memory, evicting the oldest once
+// it holds more than maxBytes, so that paging back and forth through a feed
+// doesn't download the same images again.
+type imageCache struct {
+	mu       sync.Mutex
+	images   map[string]cachedImage
+	order    []string
+	size     int
+	maxBytes int
+}
+
+var thumbnailCache = &imageCache{images: map[string]cachedImage{}, maxBytes: maxImageCacheBytes}
+
+func (ic *imageCache) get(uri string) (cachedImage, bool) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	img, ok := ic.images[uri]
+	return img, ok
+}
+
+func (ic *imageCache) put(uri string, img cachedImage) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	if _, ok := ic.images[uri]; ok {
+		return
+	}
+	ic.images[uri] = img
+	ic.order = append(ic.order, uri)
+	ic.size += len(img.data)
+	for ic.size > ic.maxBytes && len(ic.order) > 0 {
+		oldest := ic.order[0]
+		ic.order = ic.order[1:]
+		ic.size -= len(ic.images[oldest].data)
+		delete(ic.images, oldest)
+	}
+}
+
+// withIncludeImages adds the includeImages parameter to a tool that returns
+// posts.
+func withIncludeImages() mcp.ToolOption {
+	return mcp.WithBoolean("includeImages",
+		mcp.Description(fmt.Sprintf("Whether to also return thumbnails of images in posts as image content, for viewing them. At most %d images are returned. Default is false.", maxContentImages)),
+		mcp.DefaultBool(false),
+	)
+}
+
+// withImages adds the images in posts to result if the request asked for them
+// with includeImages, and returns result.
+func withImages(ctx context.Context, request mcp.CallToolRequest, result *mcp.CallToolResult, posts []postOutput) *mcp.CallToolResult {
+	if request.GetBool("includeImages", false) {
+		addImageContent(ctx, result, posts)
+	}
+	return result
+}
+
+type postImage struct {
+	postUri string
+	alt     string
+	thumb   string
+}
+
+// collectPostImages returns the images embedded in posts, including ones in
+// quoted posts, up to maxContentImages.
+func collectPostImages(posts []postOutput) []postImage {
+	var images []postImage
+	add := func(postUri string, imgs []imageOutput) {
+		for _, img := range imgs {
+			if len(images) < maxContentImages && img.Thumb != "" {
+				images = append(images, postImage{postUri, img.Alt, img.Thumb})
+			}
+		}
+	}
+	for _, p := range posts {
+		if p.Embed == nil {
+			continue
+		}
+		add(p.Uri, p.Embed.Images)
+		if r := p.Embed.Record; r != nil {
+			add(r.Uri, r.Images)
+		}
+	}
+	return images
+}
+
+// addImageContent downloads the thumbnails of images in posts and appends
+// them to result as image content, each preceded by a line saying which post
+// it's from. Images that can't be fetched are noted and skipped.
+func addImageContent(ctx context.Context, result *mcp.CallToolResult, posts []postOutput) {
+	images := collectPostImages(posts)
+	fetched := make([]cachedImage, len(images))
+	errs := make([]error, len(images))
+
+	sem := make(chan struct{}, imageFetchWorkers)
+	var wg sync.WaitGroup
+	for i, img := range images {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			fetched[i], errs[i] = fetchThumbnail(ctx, img.thumb)
+		}()
+	}
+	wg.Wait()
+
+	for i, img := range images {
+		alt := "no alt text"
+		if img.alt != "" {
+			alt = fmt.Sprintf("alt text %q", img.alt)
+		}
+		if errs[i] != nil {
+			fmt.Fprintln(os.Stderr, "Error fetching image:", errs[i])
+			result.Content = append(result.Content, mcp.NewTextContent(fmt.Sprintf("Image %d from post %s (%s) could not be loaded", i+1, img.postUri, alt)))
+			continue
+		}
+		result.Content = append(result.Content,
+			mcp.NewTextContent(fmt.Sprintf("Image %d from post %s (%s):", i+1, img.postUri, alt)),
+			mcp.NewImageContent(base64.StdEncoding.EncodeToString(fetched[i].data), fetched[i].mimeType),
+		)
+	}
+}
+
+func fetchThumbnail(ctx context.Context, uri string) (cachedImage, error) {
+	if img, ok := thumbnailCache.get(uri); ok {
+		return img, nil
+	}
+	data, err := thumbnailFetcher.FetchImage(ctx, uri)
+	if err != nil {
+		return cachedImage{}, err
+	}
+	mimeType := http.DetectContentType(data)
+	switch mimeType {
+	case "image/jpeg", "image/png", "image/webp", "image/gif":
+	default:
+		return cachedImage{}, fmt.Errorf("%s is not a supported image (%s)", uri, mimeType)
+	}
+	img := cachedImage{data, mimeType}
+	thumbnailCache.put(uri, img)
+	return img, nil
+}
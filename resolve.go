@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	xrpc "github.com/bluesky-social/indigo/xrpc"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const handleCacheTTL = 10 * time.Minute
+
+// actorDescription is the parameter description for tools that take an actor.
+const actorDescription = "Handle (e.g. alice.bsky.social), DID (e.g. did:plc:...), or bsky.app profile URL of the %s."
+
+type handleCacheEntry struct {
+	did     syntax.DID
+	expires time.Time
+}
+
+// handleCache remembers which DID each handle resolved to for a while, so
+// that mentioning or looking up the same account repeatedly only resolves its
+// handle once. Handles can change hands, so entries expire.
+type handleCache struct {
+	mu      sync.Mutex
+	entries map[syntax.Handle]handleCacheEntry
+	ttl     time.Duration
+}
+
+var resolvedHandles = &handleCache{entries: map[syntax.Handle]handleCacheEntry{}, ttl: handleCacheTTL}
+
+func (hc *handleCache) get(handle syntax.Handle) (syntax.DID, bool) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	e, ok := hc.entries[handle]
+	if !ok || time.Now().After(e.expires) {
+		delete(hc.entries, handle)
+		return "", false
+	}
+	return e.did, true
+}
+
+func (hc *handleCache) put(handle syntax.Handle, did syntax.DID) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.entries[handle] = handleCacheEntry{did, time.Now().Add(hc.ttl)}
+}
+
+// resolveHandle returns the DID that handle belongs to.
+func resolveHandle(ctx context.Context, c *xrpc.Client, handle syntax.Handle) (syntax.DID, error) {
+	handle = handle.Normalize()
+	if did, ok := resolvedHandles.get(handle); ok {
+		return did, nil
+	}
+	r, err := comatproto.IdentityResolveHandle(ctx, c, handle.String())
+	if err != nil {
+		return "", fmt.Errorf("error resolving handle %s: %w", handle, err)
+	}
+	did, err := syntax.ParseDID(r.Did)
+	if err != nil {
+		return "", fmt.Errorf("handle %s resolved to an invalid DID: %w", handle, err)
+	}
+	resolvedHandles.put(handle, did)
+	return did, nil
+}
+
+// resolveActor returns the DID of an actor given as a handle (with or without
+// a leading @), a DID, or a bsky.app profile URL.
+func resolveActor(ctx context.Context, c *xrpc.Client, actor string) (syntax.DID, error) {
+	ident := strings.TrimPrefix(strings.TrimSpace(actor), "@")
+	if strings.HasPrefix(ident, "https://") || strings.HasPrefix(ident, "http://") {
+		var err error
+		ident, err = actorFromProfileURL(ident)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	id, err := syntax.ParseAtIdentifier(ident)
+	if err != nil {
+		return "", fmt.Errorf("invalid actor %q: must be a handle, DID, or bsky.app profile URL", actor)
+	}
+	if did, err := id.AsDID(); err == nil {
+		return did, nil
+	}
+	handle, err := id.AsHandle()
+	if err != nil {
+		return "", err
+	}
+	return resolveHandle(ctx, c, handle)
+}
+
+// actorFromProfileURL returns the handle or DID in a URL like
+// https://bsky.app/profile/alice.bsky.social, or any page under it.
+func actorFromProfileURL(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if !isBskyAppHost(u.Hostname()) {
+		return "", fmt.Errorf("unsupported URL %q: only bsky.app links are supported", raw)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "profile" || parts[1] == "" {
+		return "", fmt.Errorf("%q is not a profile URL", raw)
+	}
+	return parts[1], nil
+}
+
+func isBskyAppHost(host string) bool {
+	host = strings.ToLower(host)
+	return host == "bsky.app" || host == "www.bsky.app"
+}
+
+// getActorParam resolves the actor parameter of a request. The follow tools
+// used to call it did, which they still accept as an optional alias, so
+// actor can't be marked as required in their schemas.
+func getActorParam(ctx context.Context, c *xrpc.Client, request mcp.CallToolRequest) (syntax.DID, error) {
+	actor := request.GetString("actor", request.GetString("did", ""))
+	if actor == "" {
+		return "", fmt.Errorf("required argument \"actor\" not found")
+	}
+	return resolveActor(ctx, c, actor)
+}
+
+// collectionNames are what the collections URIs can be normalized to are
+// called in errors.
+var collectionNames = map[string]string{
+	"app.bsky.feed.post":         "post",
+	"app.bsky.feed.generator":    "feed",
+	"app.bsky.graph.list":        "list",
+	"app.bsky.graph.starterpack": "starter pack",
+}
+
+// uriDescription is the parameter description for tools that take a URI.
+const uriDescription = "at-uri or bsky.app link of the %s."
+
+// normalizeURI returns the at-URI of a record given as an at-URI or as a
+// bsky.app link to a post, feed, list or starter pack, with any handle
+// resolved to a DID. If collection isn't empty, the record must be in it.
+func normalizeURI(ctx context.Context, c *xrpc.Client, raw, collection string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	var actor, coll, rkey string
+	if strings.HasPrefix(raw, "https://") || strings.HasPrefix(raw, "http://") {
+		var err error
+		actor, coll, rkey, err = parseBskyAppURL(raw)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		u, err := syntax.ParseATURI(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid URI %q: must be an at-uri or a bsky.app link", raw)
+		}
+		actor, coll, rkey = u.Authority().String(), u.Collection().String(), u.RecordKey().String()
+		if coll == "" || rkey == "" {
+			return "", fmt.Errorf("%q is not the URI of a record", raw)
+		}
+	}
+
+	if collection != "" && coll != collection {
+		return "", fmt.Errorf("%q is not a %s", raw, collectionNames[collection])
+	}
+
+	did, err := resolveActor(ctx, c, actor)
+	if err != nil {
+		return "", err
+	}
+	u, err := syntax.ParseATURI(fmt.Sprintf("at://%s/%s/%s", did, coll, rkey))
+	if err != nil {
+		return "", fmt.Errorf("invalid URI %q: %w", raw, err)
+	}
+	return u.String(), nil
+}
+
+// parseBskyAppURL splits a bsky.app link to a record into the actor, the
+// record's collection, and its record key.
+func parseBskyAppURL(raw string) (actor, collection, rkey string, err error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if !isBskyAppHost(u.Hostname()) {
+		return "", "", "", fmt.Errorf("unsupported URL %q: only bsky.app links are supported", raw)
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case len(parts) >= 4 && parts[0] == "profile" && parts[2] == "post":
+		return parts[1], "app.bsky.feed.post", parts[3], nil
+	case len(parts) >= 4 && parts[0] == "profile" && parts[2] == "feed":
+		return parts[1], "app.bsky.feed.generator", parts[3], nil
+	case len(parts) >= 4 && parts[0] == "profile" && parts[2] == "lists":
+		return parts[1], "app.bsky.graph.list", parts[3], nil
+	case len(parts) >= 3 && (parts[0] == "starter-pack" || parts[0] == "start"):
+		return parts[1], "app.bsky.graph.starterpack", parts[2], nil
+	}
+	return "", "", "", fmt.Errorf("%q is not a link to a post, feed, list or starter pack", raw)
+}
+
+// getURIParam returns the named URI parameter of a request, normalized with
+// normalizeURI. Missing optional parameters are returned as "".
+func getURIParam(ctx context.Context, c *xrpc.Client, request mcp.CallToolRequest, name, collection string, required bool) (string, error) {
+	raw := request.GetString(name, "")
+	if raw == "" {
+		if required {
+			return "", fmt.Errorf("required argument %q not found", name)
+		}
+		return "", nil
+	}
+	return normalizeURI(ctx, c, raw, collection)
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	appbsky "github.com/bluesky-social/indigo/api/bsky"
+	xrpc "github.com/bluesky-social/indigo/xrpc"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Facet detection follows the rules of the reference implementation
+// (detectFacets in @atproto/api). JavaScript's \s is wider than RE2's, so it's
+// spelled out here.
+const jsSpace = `\t\n\v\f\r\p{Zs}\x{2028}\x{2029}\x{feff}`
+
+var (
+	mentionRegex = regexp.MustCompile(`(?:^|[` + jsSpace + `(])@([a-zA-Z0-9.-]+)`)
+	linkRegex    = regexp.MustCompile(`(?i)(?:^|[` + jsSpace + `(])((https?://[^` + jsSpace + `]+)|(([a-z][a-z0-9]*(?:\.[a-z0-9]+)+)[^` + jsSpace + `]*))`)
+)
+
+// markdownLinkRegex matches [display text](url). The url may contain one level
+// of balanced parentheses, as in Wikipedia links.
+var markdownLinkRegex = regexp.MustCompile(`\[([^\[\]]+)\]\((https?://[^\s()]+(?:\([^\s()]*\)[^\s()]*)*)\)`)
+
+const maxTagLength = 64 // in UTF-16 code units, as the reference implementation counts
+
+type facetKind int
+
+const (
+	facetLink facetKind = iota
+	facetMention
+	facetTag
+)
+
+// detectedFacet is a facet found in text, before mentions are resolved to DIDs.
+// start and end are UTF-8 byte offsets. value is the link URI, the mentioned
+// handle, or the tag without its #.
+type detectedFacet struct {
+	kind  facetKind
+	start int
+	end   int
+	value string
+}
+
+// detectFacets finds links, mentions and tags in text.
+func detectFacets(text string) []detectedFacet {
+	var facets []detectedFacet
+	facets = append(facets, detectMentions(text)...)
+	facets = append(facets, detectLinks(text)...)
+	facets = append(facets, detectTags(text)...)
+	sort.SliceStable(facets, func(i, j int) bool {
+		return facets[i].start < facets[j].start
+	})
+	return facets
+}
+
+func detectMentions(text string) []detectedFacet {
+	var facets []detectedFacet
+	for _, m := range mentionRegex.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[2], m[3]
+		// the reference regex requires a word boundary after the handle
+		for end > start && !isWordBoundary(text, end) {
+			end--
+		}
+		if end == start {
+			continue
+		}
+		handle := text[start:end]
+		if !isValidDomain(handle) && !strings.HasSuffix(handle, ".test") {
+			continue // probably not a handle
+		}
+		facets = append(facets, detectedFacet{
+			kind:  facetMention,
+			start: start - 1, // include the @
+			end:   end,
+			value: handle,
+		})
+	}
+	return facets
+}
+
+func detectLinks(text string) []detectedFacet {
+	var facets []detectedFacet
+	for _, m := range linkRegex.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[2], m[3]
+		uri := text[start:end]
+		if !strings.HasPrefix(uri, "http") {
+			if m[8] < 0 || !isValidDomain(text[m[8]:m[9]]) {
+				continue
+			}
+			uri = "https://" + uri
+		}
+
+		// strip trailing punctuation
+		if strings.ContainsAny(uri[len(uri)-1:], ".,;:!?") {
+			uri = uri[:len(uri)-1]
+			end--
+		}
+		if strings.HasSuffix(uri, ")") && !strings.Contains(uri, "(") {
+			uri = uri[:len(uri)-1]
+			end--
+		}
+
+		facets = append(facets, detectedFacet{
+			kind:  facetLink,
+			start: start,
+			end:   end,
+			value: uri,
+		})
+	}
+	return facets
+}
+
+func detectTags(text string) []detectedFacet {
+	var facets []detectedFacet
+	i := 0
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r != '#' && r != '\uff03' {
+			i += size
+			continue
+		}
+		if i > 0 {
+			prev, _ := utf8.DecodeLastRuneInString(text[:i])
+			if !isJSSpace(prev) {
+				i += size
+				continue
+			}
+		}
+
+		tagStart := i + size
+		tagEnd := tagStart
+		for tagEnd < len(text) {
+			r, n := utf8.DecodeRuneInString(text[tagEnd:])
+			if isTagBreak(r) {
+				break
+			}
+			tagEnd += n
+		}
+		i = tagEnd
+
+		tag := text[tagStart:tagEnd]
+		if strings.HasPrefix(tag, "\ufe0f") || !strings.ContainsFunc(tag, isTagContent) {
+			continue
+		}
+		tag = strings.TrimRightFunc(tag, unicode.IsPunct)
+		if tag == "" || len(utf16.Encode([]rune(tag))) > maxTagLength {
+			continue
+		}
+
+		facets = append(facets, detectedFacet{
+			kind:  facetTag,
+			start: tagStart - size,
+			end:   tagStart + len(tag),
+			value: tag,
+		})
+	}
+	return facets
+}
+
+// parseMarkdownLinks replaces each [display text](url) in s with just the
+// display text, and returns the rewritten string along with link facets
+// covering the display text.
+func parseMarkdownLinks(s string) (string, []detectedFacet) {
+	var facets []detectedFacet
+	var b strings.Builder
+	last := 0
+	for _, m := range findMarkdownLinks(s) {
+		display := s[m[2]:m[3]]
+		uri := s[m[4]:m[5]]
+
+		b.WriteString(s[last:m[0]])
+		start := b.Len()
+		b.WriteString(display)
+		facets = append(facets, detectedFacet{
+			kind:  facetLink,
+			start: start,
+			end:   b.Len(),
+			value: uri,
+		})
+		last = m[1]
+	}
+	b.WriteString(s[last:])
+	return b.String(), facets
+}
+
+// findMarkdownLinks returns the submatch indices of the markdown links in s
+// that parseMarkdownLinks will rewrite: ones with display text and a valid url.
+func findMarkdownLinks(s string) [][]int {
+	var links [][]int
+	for _, m := range markdownLinkRegex.FindAllStringSubmatchIndex(s, -1) {
+		if strings.TrimSpace(s[m[2]:m[3]]) == "" {
+			continue
+		}
+		if _, err := url.ParseRequestURI(s[m[4]:m[5]]); err != nil {
+			continue
+		}
+		links = append(links, m)
+	}
+	return links
+}
+
+// isValidDomain reports whether s ends in a known top level domain. Like the
+// reference implementation, only the last label is checked, so a name that is
+// itself a public suffix (github.io, co.uk) still counts as a domain.
+func isValidDomain(s string) bool {
+	i := strings.LastIndex(s, ".")
+	if i < 0 || i == len(s)-1 {
+		return false
+	}
+	// the public suffix list has an ICANN rule for every TLD; unknown TLDs
+	// fall through to the "*" rule, which isn't one. Looking up a name under
+	// the TLD rather than the TLD itself also covers wildcard TLDs like *.ck.
+	_, icann := publicsuffix.PublicSuffix("x." + strings.ToLower(s[i+1:]))
+	return icann
+}
+
+func isWordBoundary(text string, i int) bool {
+	before := i > 0 && isWordByte(text[i-1])
+	after := i < len(text) && isWordByte(text[i])
+	return before != after
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9')
+}
+
+func isJSSpace(r rune) bool {
+	switch r {
+	case '\t', '\n', '\v', '\f', '\r', '\u2028', '\u2029', '\ufeff':
+		return true
+	}
+	return unicode.Is(unicode.Zs, r)
+}
+
+// isTagBreak reports whether r ends a tag: whitespace, or an invisible
+// character that would otherwise let a tag look shorter than it is.
+func isTagBreak(r rune) bool {
+	switch r {
+	case '\u00ad', '\u2060', '\u200a', '\u200b', '\u200c', '\u200d', '\u20e2':
+		return true
+	}
+	return isJSSpace(r)
+}
+
+// isTagContent reports whether r can make a tag valid: tags made only of
+// digits and punctuation aren't tags.
+func isTagContent(r rune) bool {
+	return !('0' <= r && r <= '9') && !isTagBreak(r) && !unicode.IsPunct(r)
+}
+
+// getFacetsFromString rewrites markdown links in s, detects facets in the
+// result, and converts them into richtext facets, resolving mentions to DIDs.
+// Mentions that can't be resolved are dropped. Returns the rewritten text,
+// which is what should be posted.
+func getFacetsFromString(ctx context.Context, c *xrpc.Client, s string) (string, []*appbsky.RichtextFacet) {
+	text, detected := parseMarkdownLinks(s)
+	for _, f := range detectFacets(text) {
+		// markdown links win over anything detected inside their display text
+		overlaps := false
+		for _, md := range detected {
+			if f.start < md.end && md.start < f.end {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			detected = append(detected, f)
+		}
+	}
+	sort.SliceStable(detected, func(i, j int) bool {
+		return detected[i].start < detected[j].start
+	})
+
+	var facets []*appbsky.RichtextFacet
+	for _, f := range detected {
+		feature := &appbsky.RichtextFacet_Features_Elem{}
+		switch f.kind {
+		case facetLink:
+			feature.RichtextFacet_Link = &appbsky.RichtextFacet_Link{Uri: f.value}
+		case facetTag:
+			feature.RichtextFacet_Tag = &appbsky.RichtextFacet_Tag{Tag: f.value}
+		case facetMention:
+			handle, err := syntax.ParseHandle(f.value)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Skipping invalid handle:", f.value)
+				continue
+			}
+			did, err := resolveHandle(ctx, c, handle)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error resolving handle:", err)
+				continue
+			}
+			feature.RichtextFacet_Mention = &appbsky.RichtextFacet_Mention{Did: did.String()}
+		}
+
+		facets = append(facets, &appbsky.RichtextFacet{
+			Features: []*appbsky.RichtextFacet_Features_Elem{feature},
+			Index: &appbsky.RichtextFacet_ByteSlice{
+				ByteStart: int64(f.start),
+				ByteEnd:   int64(f.end),
+			},
+		})
+	}
+	return text, facets
+}
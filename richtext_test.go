@@ -0,0 +1,199 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// These cases are ported from the reference implementation's detection tests
+// (rich-text-detection.test.ts in @atproto/api).
+
+// segment is a run of text, with the facet value it's covered by if any.
+type segment struct {
+	text  string
+	value string
+}
+
+// segmentText splits text into the runs covered and not covered by facets.
+func segmentText(text string, facets []detectedFacet) []segment {
+	var segs []segment
+	last := 0
+	for _, f := range facets {
+		if f.start > last {
+			segs = append(segs, segment{text[last:f.start], ""})
+		}
+		segs = append(segs, segment{text[f.start:f.end], f.value})
+		last = f.end
+	}
+	if last < len(text) {
+		segs = append(segs, segment{text[last:], ""})
+	}
+	return segs
+}
+
+func TestDetectMentionsAndLinks(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []segment
+	}{
+		{"no mention", []segment{{"no mention", ""}}},
+		{"@handle.com middle end", []segment{{"@handle.com", "handle.com"}, {" middle end", ""}}},
+		{"start @handle.com end", []segment{{"start ", ""}, {"@handle.com", "handle.com"}, {" end", ""}}},
+		{"start middle @handle.com", []segment{{"start middle ", ""}, {"@handle.com", "handle.com"}}},
+		{"@handle.com @handle.com @handle.com", []segment{
+			{"@handle.com", "handle.com"}, {" ", ""}, {"@handle.com", "handle.com"}, {" ", ""}, {"@handle.com", "handle.com"},
+		}},
+		{"@full123-chars.test", []segment{{"@full123-chars.test", "full123-chars.test"}}},
+		{"not@right", []segment{{"not@right", ""}}},
+		{"@handle.com!@#$chars", []segment{{"@handle.com", "handle.com"}, {"!@#$chars", ""}}},
+		{"@handle.com\n@handle.com", []segment{{"@handle.com", "handle.com"}, {"\n", ""}, {"@handle.com", "handle.com"}}},
+		{"parenthetical (@handle.com)", []segment{{"parenthetical (", ""}, {"@handle.com", "handle.com"}, {")", ""}}},
+		{"check @github.io out", []segment{{"check ", ""}, {"@github.io", "github.io"}, {" out", ""}}},
+
+		{"start https://middle.com end", []segment{{"start ", ""}, {"https://middle.com", "https://middle.com"}, {" end", ""}}},
+		{"start https://middle.com/foo/bar end", []segment{{"start ", ""}, {"https://middle.com/foo/bar", "https://middle.com/foo/bar"}, {" end", ""}}},
+		{"start https://middle.com/foo/bar?baz=bux end", []segment{{"start ", ""}, {"https://middle.com/foo/bar?baz=bux", "https://middle.com/foo/bar?baz=bux"}, {" end", ""}}},
+		{"start https://middle.com/foo/bar?baz=bux#hash end", []segment{{"start ", ""}, {"https://middle.com/foo/bar?baz=bux#hash", "https://middle.com/foo/bar?baz=bux#hash"}, {" end", ""}}},
+		{"https://start.com/foo/bar?baz=bux#hash middle end", []segment{{"https://start.com/foo/bar?baz=bux#hash", "https://start.com/foo/bar?baz=bux#hash"}, {" middle end", ""}}},
+		{"start middle https://end.com/foo/bar?baz=bux#hash", []segment{{"start middle ", ""}, {"https://end.com/foo/bar?baz=bux#hash", "https://end.com/foo/bar?baz=bux#hash"}}},
+		{"https://newline1.com\nhttps://newline2.com", []segment{{"https://newline1.com", "https://newline1.com"}, {"\n", ""}, {"https://newline2.com", "https://newline2.com"}}},
+
+		{"start middle.com end", []segment{{"start ", ""}, {"middle.com", "https://middle.com"}, {" end", ""}}},
+		{"start middle.com/foo/bar end", []segment{{"start ", ""}, {"middle.com/foo/bar", "https://middle.com/foo/bar"}, {" end", ""}}},
+		{"start middle.com/foo/bar?baz=bux end", []segment{{"start ", ""}, {"middle.com/foo/bar?baz=bux", "https://middle.com/foo/bar?baz=bux"}, {" end", ""}}},
+		{"start middle.com/foo/bar?baz=bux#hash end", []segment{{"start ", ""}, {"middle.com/foo/bar?baz=bux#hash", "https://middle.com/foo/bar?baz=bux#hash"}, {" end", ""}}},
+		{"start.com/foo/bar?baz=bux#hash middle end", []segment{{"start.com/foo/bar?baz=bux#hash", "https://start.com/foo/bar?baz=bux#hash"}, {" middle end", ""}}},
+		{"start middle end.com/foo/bar?baz=bux#hash", []segment{{"start middle ", ""}, {"end.com/foo/bar?baz=bux#hash", "https://end.com/foo/bar?baz=bux#hash"}}},
+		{"newline1.com\nnewline2.com", []segment{{"newline1.com", "https://newline1.com"}, {"\n", ""}, {"newline2.com", "https://newline2.com"}}},
+		{"check github.io out", []segment{{"check ", ""}, {"github.io", "https://github.io"}, {" out", ""}}},
+		{"co.uk", []segment{{"co.uk", "https://co.uk"}}},
+		{"blogspot.com", []segment{{"blogspot.com", "https://blogspot.com"}}},
+
+		{"not.. a..url ..here", []segment{{"not.. a..url ..here", ""}}},
+		{"e.g.", []segment{{"e.g.", ""}}},
+		{"something-cool.jpg", []segment{{"something-cool.jpg", ""}}},
+		{"website.com.jpg", []segment{{"website.com.jpg", ""}}},
+		{"e.g./foo", []segment{{"e.g./foo", ""}}},
+		{"website.com.jpg/foo", []segment{{"website.com.jpg/foo", ""}}},
+		{"Classic article https://socket3.wordpress.com/2018/02/03/designing-windows-95s-user-interface/", []segment{
+			{"Classic article ", ""},
+			{"https://socket3.wordpress.com/2018/02/03/designing-windows-95s-user-interface/", "https://socket3.wordpress.com/2018/02/03/designing-windows-95s-user-interface/"},
+		}},
+		{"Classic article https://socket3.wordpress.com/2018/02/03/designing-windows-95s-user-interface/ ", []segment{
+			{"Classic article ", ""},
+			{"https://socket3.wordpress.com/2018/02/03/designing-windows-95s-user-interface/", "https://socket3.wordpress.com/2018/02/03/designing-windows-95s-user-interface/"},
+			{" ", ""},
+		}},
+		{"https://foo.com https://bar.com/whatever https://baz.com", []segment{
+			{"https://foo.com", "https://foo.com"}, {" ", ""}, {"https://bar.com/whatever", "https://bar.com/whatever"}, {" ", ""}, {"https://baz.com", "https://baz.com"},
+		}},
+		{"punctuation https://foo.com, https://bar.com/whatever; https://baz.com.", []segment{
+			{"punctuation ", ""}, {"https://foo.com", "https://foo.com"}, {", ", ""}, {"https://bar.com/whatever", "https://bar.com/whatever"}, {"; ", ""}, {"https://baz.com", "https://baz.com"}, {".", ""},
+		}},
+		{"parenthentical (https://foo.com)", []segment{{"parenthentical (", ""}, {"https://foo.com", "https://foo.com"}, {")", ""}}},
+		{"except for https://foo.com/thing_(cool)", []segment{{"except for ", ""}, {"https://foo.com/thing_(cool)", "https://foo.com/thing_(cool)"}}},
+	}
+
+	for _, tt := range tests {
+		got := segmentText(tt.input, detectFacets(tt.input))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("detectFacets(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestDetectTags(t *testing.T) {
+	// tags can be up to 64 UTF-16 code units long, so an emoji counts as two
+	tag64 := "thisisa64characterstring_" + strings.Repeat("a", 39)
+	tag65 := "thisisa65characterstring_" + strings.Repeat("a", 40)
+	emojiTag64 := "🦋" + strings.Repeat("a", 62)
+
+	type index struct{ start, end int }
+	tests := []struct {
+		input   string
+		tags    []string
+		indices []index
+	}{
+		{"#a", []string{"a"}, []index{{0, 2}}},
+		{"#a #b", []string{"a", "b"}, []index{{0, 2}, {3, 5}}},
+		{"#1", nil, nil},
+		{"#1a", []string{"1a"}, []index{{0, 3}}},
+		{"#tag", []string{"tag"}, []index{{0, 4}}},
+		{"body #tag", []string{"tag"}, []index{{5, 9}}},
+		{"#tag body", []string{"tag"}, []index{{0, 4}}},
+		{"body #tag body", []string{"tag"}, []index{{5, 9}}},
+		{"body #1", nil, nil},
+		{"body #1a", []string{"1a"}, []index{{5, 8}}},
+		{"body #a1", []string{"a1"}, []index{{5, 8}}},
+		{"#", nil, nil},
+		{"#?", nil, nil},
+		{"text #", nil, nil},
+		{"text # text", nil, nil},
+		{"body #" + tag64, []string{tag64}, []index{{5, 70}}},
+		{"body #" + tag65, nil, nil},
+		{"body #" + tag64 + "!", []string{tag64}, []index{{5, 70}}},
+		{"body #" + emojiTag64, []string{emojiTag64}, []index{{5, 72}}},
+		{"body #" + emojiTag64 + "a", nil, nil},
+		{"its a #double#rainbow", []string{"double#rainbow"}, []index{{6, 21}}},
+		{"##hashash", []string{"#hashash"}, []index{{0, 9}}},
+		{"##", nil, nil},
+		{"some #n0n3s@n5e!", []string{"n0n3s@n5e"}, []index{{5, 15}}},
+		{"works #with,punctuation", []string{"with,punctuation"}, []index{{6, 23}}},
+		{"strips trailing #punctuation, #like. #this!", []string{"punctuation", "like", "this"}, []index{{16, 28}, {30, 35}, {37, 42}}},
+		{"strips #multi_trailing___...", []string{"multi_trailing"}, []index{{7, 22}}},
+		{"works with #🦋 emoji, and #butter🦋fly", []string{"🦋", "butter🦋fly"}, []index{{11, 16}, {28, 42}}},
+		{"#same #same #but #diff", []string{"same", "same", "but", "diff"}, []index{{0, 5}, {6, 11}, {12, 16}, {17, 22}}},
+		{"this #️⃣tag should not be a tag", nil, nil},
+		{"this ##️⃣tag should be a tag", []string{"#️⃣tag"}, []index{{5, 16}}},
+		{"this #t\nag should be a tag", []string{"t"}, []index{{5, 7}}},
+		{"no match (\\u200B): #​", nil, nil},
+		{"no match (\\u200Ba): #​a", nil, nil},
+		{"match (a\\u200Bb): #a​b", []string{"a"}, []index{{18, 20}}},
+		{"match (ab\\u200B): #ab​", []string{"ab"}, []index{{18, 21}}},
+		{"no match (\\u20e2tag): #⃢tag", nil, nil},
+		{"no match (a\\u20e2b): #a⃢b", []string{"a"}, []index{{21, 23}}},
+		{"match full width number sign (tag): ＃tag", []string{"tag"}, []index{{36, 42}}},
+		{"match full width number sign (tag): ＃#️⃣tag", []string{"#️⃣tag"}, []index{{36, 49}}},
+		{"no match 1?: #1?", nil, nil},
+		{"#️⃣", nil, nil},
+	}
+
+	for _, tt := range tests {
+		var tags []string
+		var indices []index
+		for _, f := range detectTags(tt.input) {
+			tags = append(tags, f.value)
+			indices = append(indices, index{f.start, f.end})
+		}
+		if !slices.Equal(tags, tt.tags) {
+			t.Errorf("detectTags(%q) tags = %q, want %q", tt.input, tags, tt.tags)
+		}
+		if !slices.Equal(indices, tt.indices) {
+			t.Errorf("detectTags(%q) indices = %v, want %v", tt.input, indices, tt.indices)
+		}
+	}
+}
+
+func TestIsValidDomain(t *testing.T) {
+	tests := []struct {
+		domain string
+		want   bool
+	}{
+		{"example.com", true},
+		{"EXAMPLE.COM", true},
+		{"github.io", true},
+		{"co.uk", true},
+		{"blogspot.com", true},
+		{"foo.bar.social", true},
+		{"website.com.jpg", false},
+		{"e.g", false},
+		{"com", false},
+		{"example.", false},
+	}
+	for _, tt := range tests {
+		if got := isValidDomain(tt.domain); got != tt.want {
+			t.Errorf("isValidDomain(%q) = %v, want %v", tt.domain, got, tt.want)
+		}
+	}
+}
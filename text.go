@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+const (
+	maxPostGraphemes = 300  // what Bluesky shows as the character limit
+	maxPostBytes     = 3000 // hard limit on the UTF-8 length of post text
+)
+
+// splitGraphemes splits s into extended grapheme clusters, i.e. what a user
+// would think of as characters.
+func splitGraphemes(s string) []string {
+	var graphemes []string
+	g := uniseg.NewGraphemes(s)
+	for g.Next() {
+		graphemes = append(graphemes, g.Str())
+	}
+	return graphemes
+}
+
+// countGraphemes returns the number of extended grapheme clusters in s.
+func countGraphemes(s string) int {
+	return uniseg.GraphemeClusterCount(s)
+}
+
+// displayText returns text as it will appear once posted, with markdown links
+// reduced to their display text.
+func displayText(text string) string {
+	s, _ := parseMarkdownLinks(text)
+	return s
+}
+
+// checkPostText returns an error if text is too long to be the text of a post.
+// The error states the actual length and, when over the grapheme limit, where
+// the text would be split to make it fit.
+func checkPostText(text string) error {
+	graphemes := splitGraphemes(text)
+	if len(graphemes) > maxPostGraphemes {
+		cut := findThreadBreak(graphemes, 0, maxPostGraphemes, nil)
+		tail := graphemes[max(cut-20, 0):cut]
+		return fmt.Errorf("text is %d characters long, exceeding the maximum of %d characters. It could be split after character %d (\"...%s\"), or posted as a thread with createThread",
+			len(graphemes), maxPostGraphemes, cut, strings.Join(tail, ""))
+	}
+	if len(text) > maxPostBytes {
+		return fmt.Errorf("text is %d bytes long, exceeding the maximum of %d bytes", len(text), maxPostBytes)
+	}
+	return nil
+}
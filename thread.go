@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	appbsky "github.com/bluesky-social/indigo/api/bsky"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	xrpc "github.com/bluesky-social/indigo/xrpc"
+
+	"github.com/bluesky-social/indigo/atproto/data"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+)
+
+const maxThreadSegments = 50
+
+// splitThreadText splits text into segments of at most limit graphemes,
+// preferring to break at paragraphs, then sentences, then words. Markdown
+// links only count as their display text, since that's all that gets posted,
+// and neither they nor bare links are ever cut in two.
+func splitThreadText(text string, limit int) []string {
+	text = strings.TrimSpace(text)
+	graphemes := splitGraphemes(text)
+
+	// offsets[i] is the byte offset grapheme i starts at
+	offsets := make([]int, len(graphemes)+1)
+	for i, g := range graphemes {
+		offsets[i+1] = offsets[i] + len(g)
+	}
+	index := func(offset int) int {
+		i, _ := slices.BinarySearch(offsets, offset)
+		return i
+	}
+
+	// hidden graphemes are the markup around a markdown link's display text
+	hidden := make([]bool, len(graphemes))
+	var protected [][2]int
+	for _, m := range findMarkdownLinks(text) {
+		protected = append(protected, [2]int{index(m[0]), index(m[1])})
+		for i := index(m[0]); i < index(m[2]); i++ {
+			hidden[i] = true
+		}
+		for i := index(m[3]); i < index(m[1]); i++ {
+			hidden[i] = true
+		}
+	}
+	for _, f := range detectLinks(text) {
+		protected = append(protected, [2]int{index(f.start), index(f.end)})
+	}
+
+	var segments []string
+	start := 0
+	for start < len(graphemes) {
+		end, n := start, 0
+		for end < len(graphemes) && (hidden[end] || n < limit) {
+			if !hidden[end] {
+				n++
+			}
+			end++
+		}
+		if end >= len(graphemes) {
+			segments = append(segments, strings.TrimSpace(strings.Join(graphemes[start:], "")))
+			break
+		}
+
+		cut := findThreadBreak(graphemes, start, end, protected)
+		if seg := strings.TrimSpace(strings.Join(graphemes[start:cut], "")); seg != "" {
+			segments = append(segments, seg)
+		}
+		start = cut
+		for start < len(graphemes) && isSpaceGrapheme(graphemes[start]) {
+			start++
+		}
+	}
+	return segments
+}
+
+// findThreadBreak picks the index to cut graphemes[start:end] at, never cutting
+// inside one of the protected ranges. Paragraph and sentence breaks are only
+// used if they're past the halfway point, so that segments don't end up tiny.
+// If there's nowhere to break and end falls inside a protected range, the cut
+// is moved before the range, or after it if the range is all there is, in
+// which case the segment ends up over the limit.
+func findThreadBreak(graphemes []string, start, end int, protected [][2]int) int {
+	half := start + (end-start)/2
+	word := -1
+	sentence := -1
+	for i := end; i > start; i-- {
+		if isProtected(i, protected) {
+			continue
+		}
+		prev := graphemes[i-1]
+		if i > half && prev == "\n" && i > 1 && graphemes[i-2] == "\n" {
+			return i
+		}
+		if sentence < 0 && i > half && isSpaceGrapheme(graphemes[i]) && strings.ContainsAny(prev, ".!?") {
+			sentence = i
+		}
+		if word < 0 && isSpaceGrapheme(graphemes[i]) {
+			word = i
+		}
+	}
+	if sentence >= 0 {
+		return sentence
+	}
+	if word > start {
+		return word
+	}
+	for _, r := range protected {
+		if r[0] < end && end < r[1] {
+			if r[0] > start {
+				return r[0]
+			}
+			return r[1]
+		}
+	}
+	return end
+}
+
+func isProtected(i int, protected [][2]int) bool {
+	for _, r := range protected {
+		if r[0] < i && i < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func isSpaceGrapheme(s string) bool {
+	return strings.TrimFunc(s, unicode.IsSpace) == ""
+}
+
+// makeThreadWrites builds one create operation per segment, chaining each post
+// as a reply to the previous one. Record keys and CIDs are computed locally so
+// that the whole thread can be written in a single applyWrites call.
+func makeThreadWrites(ctx context.Context, c *xrpc.Client, segments []string, reply *appbsky.FeedPost_ReplyRef) ([]*comatproto.RepoApplyWrites_Input_Writes_Elem, []*comatproto.RepoStrongRef, error) {
+	clock := syntax.NewTIDClock(0)
+	var writes []*comatproto.RepoApplyWrites_Input_Writes_Elem
+	var refs []*comatproto.RepoStrongRef
+
+	for _, seg := range segments {
+		text, facets := getFacetsFromString(ctx, c, seg)
+		post := &appbsky.FeedPost{
+			LexiconTypeID: "app.bsky.feed.post",
+			CreatedAt:     syntax.DatetimeNow().String(),
+			Text:          text,
+			Facets:        facets,
+			Reply:         reply,
+		}
+		rec := &lexutil.LexiconTypeDecoder{Val: post}
+		recCid, err := recordCID(rec)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error computing post CID: %w", err)
+		}
+
+		rkey := clock.Next().String()
+		writes = append(writes, &comatproto.RepoApplyWrites_Input_Writes_Elem{
+			RepoApplyWrites_Create: &comatproto.RepoApplyWrites_Create{
+				Collection: "app.bsky.feed.post",
+				Rkey:       &rkey,
+				Value:      rec,
+			},
+		})
+
+		ref := &comatproto.RepoStrongRef{
+			Cid: recCid,
+			Uri: fmt.Sprintf("at://%s/app.bsky.feed.post/%s", c.Auth.Did, rkey),
+		}
+		refs = append(refs, ref)
+
+		root := ref
+		if reply != nil {
+			root = reply.Root
+		}
+		reply = &appbsky.FeedPost_ReplyRef{
+			Parent: ref,
+			Root:   root,
+		}
+	}
+	return writes, refs, nil
+}
+
+// recordCID computes the CID the PDS will assign to rec, by normalizing it the
+// same way: JSON to atproto data, then DAG-CBOR.
+func recordCID(rec *lexutil.LexiconTypeDecoder) (string, error) {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return "", err
+	}
+	obj, err := data.UnmarshalJSON(b)
+	if err != nil {
+		return "", err
+	}
+	cb, err := data.MarshalCBOR(obj)
+	if err != nil {
+		return "", err
+	}
+	c, err := cid.NewPrefixV1(cid.DagCBOR, multihash.SHA2_256).Sum(cb)
+	if err != nil {
+		return "", err
+	}
+	return c.String(), nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitThreadText(t *testing.T) {
+	link := "[the docs](https://example.com/" + strings.Repeat("x", 200) + ")"
+	longURL := "https://example.com/" + strings.Repeat("y", 60)
+
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{
+			name:  "fits",
+			text:  "short post",
+			limit: 300,
+			want:  []string{"short post"},
+		},
+		{
+			name:  "words",
+			text:  "one two three four",
+			limit: 9,
+			want:  []string{"one two", "three", "four"},
+		},
+		{
+			name:  "markdown urls don't count",
+			text:  "see " + link + " for more",
+			limit: 30,
+			want:  []string{"see " + link + " for more"},
+		},
+		{
+			name:  "markdown links aren't split",
+			text:  "read [all of the docs](https://example.com) now",
+			limit: 12,
+			want:  []string{"read", "[all of the docs](https://example.com)", "now"},
+		},
+		{
+			name:  "bare links aren't split",
+			text:  longURL + " tail",
+			limit: 40,
+			want:  []string{longURL, "tail"},
+		},
+		{
+			name:  "cut before a bare link",
+			text:  "abc(" + longURL + ")",
+			limit: 40,
+			want:  []string{"abc(", longURL, ")"},
+		},
+	}
+
+	for _, tt := range tests {
+		got := splitThreadText(tt.text, tt.limit)
+		if strings.Join(got, "\x00") != strings.Join(tt.want, "\x00") {
+			t.Errorf("%s: splitThreadText(%q, %d) = %q, want %q", tt.name, tt.text, tt.limit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	appbsky "github.com/bluesky-social/indigo/api/bsky"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	xrpc "github.com/bluesky-social/indigo/xrpc"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	maxVideoBytes      = 100_000_000 // app.bsky.embed.video allows up to 100mb
+	maxCaptionBytes    = 20_000
+	videoPollInterval  = 2 * time.Second
+	videoProcessingMax = 10 * time.Minute
+)
+
+type videoInput struct {
+	Path     string         `json:"path"`
+	Alt      string         `json:"alt"`
+	Width    int64          `json:"width"`
+	Height   int64          `json:"height"`
+	Captions []captionInput `json:"captions"`
+}
+
+type captionInput struct {
+	Lang string `json:"lang"`
+	Path string `json:"path"`
+}
+
+// videoServiceURL returns the video service host, which can be overridden with
+// BSKY_VIDEO_SERVICE (e.g. to point at a local stand-in).
+func videoServiceURL() string {
+	if u := os.Getenv("BSKY_VIDEO_SERVICE"); u != "" {
+		return strings.TrimSuffix(u, "/")
+	}
+	return "https://video.bsky.app"
+}
+
+// getVideoInput reads the "video" argument of a tool request.
+func getVideoInput(request mcp.CallToolRequest) (*videoInput, error) {
+	raw, ok := request.GetArguments()["video"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var in videoInput
+	if err := json.Unmarshal(b, &in); err != nil {
+		return nil, fmt.Errorf("video must be an object: %w", err)
+	}
+	if in.Path == "" {
+		return nil, fmt.Errorf("video is missing a path")
+	}
+	if (in.Width == 0) != (in.Height == 0) {
+		return nil, fmt.Errorf("video width and height must be provided together")
+	}
+	for i, caption := range in.Captions {
+		if caption.Lang == "" || caption.Path == "" {
+			return nil, fmt.Errorf("caption %d must have a lang and a path", i+1)
+		}
+	}
+	return &in, nil
+}
+
+// makeVideoEmbed uploads a video through the video service, waits for it to
+// finish processing, and builds an app.bsky.embed.video embed from the result.
+func makeVideoEmbed(ctx context.Context, c *xrpc.Client, request mcp.CallToolRequest, in *videoInput) (*appbsky.EmbedVideo, error) {
+	data, err := os.ReadFile(in.Path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxVideoBytes {
+		return nil, fmt.Errorf("video is %d bytes, exceeding the limit of %d bytes", len(data), maxVideoBytes)
+	}
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "video/") {
+		mimeType = mime.TypeByExtension(filepath.Ext(in.Path))
+	}
+	if !strings.HasPrefix(mimeType, "video/") {
+		return nil, fmt.Errorf("unsupported video type for %s", in.Path)
+	}
+
+	sendProgress(ctx, request, 0, 100, "Uploading video")
+	job, err := uploadVideo(ctx, c, data, mimeType, filepath.Base(in.Path))
+	if err != nil {
+		return nil, err
+	}
+	blob, err := waitForVideo(ctx, request, job)
+	if err != nil {
+		return nil, err
+	}
+
+	embed := &appbsky.EmbedVideo{Video: blob}
+	if in.Alt != "" {
+		embed.Alt = &in.Alt
+	}
+	if in.Width > 0 {
+		embed.AspectRatio = &appbsky.EmbedDefs_AspectRatio{Width: in.Width, Height: in.Height}
+	}
+	for _, caption := range in.Captions {
+		vtt, err := os.ReadFile(caption.Path)
+		if err != nil {
+			return nil, err
+		}
+		if len(vtt) > maxCaptionBytes {
+			return nil, fmt.Errorf("caption file %s is %d bytes, exceeding the limit of %d bytes", caption.Path, len(vtt), maxCaptionBytes)
+		}
+		capBlob, err := uploadBlob(ctx, c, vtt, "text/vtt")
+		if err != nil {
+			return nil, fmt.Errorf("caption %s: %w", caption.Path, err)
+		}
+		embed.Captions = append(embed.Captions, &appbsky.EmbedVideo_Caption{
+			File: capBlob,
+			Lang: caption.Lang,
+		})
+	}
+	return embed, nil
+}
+
+// uploadVideo sends the video to the video service, authenticating with a
+// service token that lets the video service upload the blob to our PDS.
+func uploadVideo(ctx context.Context, c *xrpc.Client, data []byte, mimeType, name string) (*appbsky.VideoDefs_JobStatus, error) {
+	pds, err := url.Parse(c.Host)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing PDS host: %w", err)
+	}
+	auth, err := comatproto.ServerGetServiceAuth(ctx, c, "did:web:"+pds.Hostname(), time.Now().Add(30*time.Minute).Unix(), "com.atproto.repo.uploadBlob")
+	if err != nil {
+		return nil, fmt.Errorf("error getting service auth: %w", err)
+	}
+
+	params := url.Values{}
+	params.Set("did", c.Auth.Did)
+	params.Set("name", name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, videoServiceURL()+"/xrpc/app.bsky.video.uploadVideo?"+params.Encode(), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+auth.Token)
+	req.Header.Set("Content-Type", mimeType)
+	req.Header.Set("User-Agent", *userAgent())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error uploading video: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading video upload response: %w", err)
+	}
+
+	// the service answers with a bare job status, including on 409 when the
+	// same video has already been uploaded
+	var out struct {
+		appbsky.VideoDefs_JobStatus
+		JobStatus *appbsky.VideoDefs_JobStatus `json:"jobStatus"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("error uploading video: status %d: %s", resp.StatusCode, body)
+	}
+	job := &out.VideoDefs_JobStatus
+	if out.JobStatus != nil {
+		job = out.JobStatus
+	}
+	if job.JobId == "" {
+		return nil, fmt.Errorf("error uploading video: status %d: %s", resp.StatusCode, body)
+	}
+	return job, nil
+}
+
+// waitForVideo polls the job until processing completes, reporting progress to the client.
+func waitForVideo(ctx context.Context, request mcp.CallToolRequest, job *appbsky.VideoDefs_JobStatus) (*lexutil.LexBlob, error) {
+	ctx, cancel := context.WithTimeout(ctx, videoProcessingMax)
+	defer cancel()
+
+	vc := &xrpc.Client{
+		Client:    &http.Client{},
+		Host:      videoServiceURL(),
+		UserAgent: userAgent(),
+	}
+
+	for {
+		switch job.State {
+		case "JOB_STATE_COMPLETED":
+			if job.Blob == nil {
+				return nil, fmt.Errorf("video processing completed without a blob")
+			}
+			sendProgress(ctx, request, 100, 100, "Video processed")
+			return job.Blob, nil
+		case "JOB_STATE_FAILED":
+			msg := "unknown error"
+			if job.Error != nil {
+				msg = *job.Error
+			} else if job.Message != nil {
+				msg = *job.Message
+			}
+			return nil, fmt.Errorf("video processing failed: %s", msg)
+		}
+		if job.Blob != nil {
+			// the service may hand back the blob before marking the job complete
+			return job.Blob, nil
+		}
+
+		var progress int64
+		if job.Progress != nil {
+			progress = *job.Progress
+		}
+		sendProgress(ctx, request, float64(progress), 100, fmt.Sprintf("Processing video (%s)", job.State))
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for video processing: %w", ctx.Err())
+		case <-time.After(videoPollInterval):
+		}
+
+		r, err := appbsky.VideoGetJobStatus(ctx, vc, job.JobId)
+		if err != nil {
+			return nil, fmt.Errorf("error getting video job status: %w", err)
+		}
+		if r.JobStatus == nil {
+			return nil, fmt.Errorf("video service returned an empty job status")
+		}
+		job = r.JobStatus
+	}
+}
+
+// sendProgress sends a progress notification if the client asked for them.
+func sendProgress(ctx context.Context, request mcp.CallToolRequest, progress, total float64, message string) {
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return
+	}
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return
+	}
+	err := srv.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+		"progressToken": request.Params.Meta.ProgressToken,
+		"progress":      progress,
+		"total":         total,
+		"message":       message,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error sending progress notification:", err)
+	}
+}